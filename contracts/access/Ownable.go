@@ -17,8 +17,11 @@ limitations under the License.
 package access
 
 import (
+	"encoding/json"
+
 	"github.com/pkg/errors"
 
+	"github.com/bestchains/bestchains-contracts/contracts/nonce"
 	"github.com/bestchains/bestchains-contracts/library"
 	"github.com/bestchains/bestchains-contracts/library/context"
 	"github.com/bestchains/bestchains-contracts/library/initializable"
@@ -28,37 +31,69 @@ import (
 const (
 	OwnableInitializedKey = "owner~initialized"
 	OwnerKey              = "owner~account"
+	PendingOwnerKey       = "owner~pending"
+)
+
+var (
+	ErrNoPendingOwner           = errors.New("Ownable: no pending owner")
+	ErrNotPendingOwner          = errors.New("Ownable: caller is not the pending owner")
+	ErrOwnershipTransferExpired = errors.New("Ownable: ownership transfer expired")
 )
 
+// PendingOwnership is the nominated owner who has not accepted the ownership yet
+type PendingOwnership struct {
+	PendingOwner library.Address `json:"pendingOwner"`
+	// Deadline is the unix timestamp in seconds until which the ownership can be accepted,0 if no deadline
+	Deadline int64 `json:"deadline"`
+}
+
 var _ IOwnable = new(OwnableContract)
 
 // OwnableContract implements IOwnable which provides basic access control mechanism where there is an owner can be granted exclusive access to specific functions.
 type OwnableContract struct {
 	contractapi.Contract
+
+	nonce.INonce
+
 	initializable *initializable.Initializable
+
+	hooks *context.Hooks
 }
 
-func NewOwnableContract() *OwnableContract {
+// NewOwnableContract creates an OwnableContract with the given nonce contract,
+// which can only be initialized by the operators passing all policies,
+// otherwise whoever initializes it first becomes the owner.
+func NewOwnableContract(nonceContract nonce.INonce, policies []initializable.Policy, opts ...context.HookOption) *OwnableContract {
 	ownable := new(OwnableContract)
 	ownable.Name = "org.bestchains.com.OwnableContract"
+	ownable.INonce = nonceContract
 	ownable.TransactionContextHandler = new(context.Context)
-	ownable.initializable = &initializable.Initializable{}
-	ownable.BeforeTransaction = context.BeforeTransaction
+	ownable.initializable = initializable.NewInitializable(policies...)
+	ownable.hooks = context.NewHooks(opts...)
+	ownable.hooks.Register(ownable)
+	ownable.BeforeTransaction = ownable.hooks.BeforeTransaction
+	ownable.AfterTransaction = ownable.hooks.AfterTransaction
 
 	return ownable
 }
 
-// Initialize will set the owner
-func (ownable *OwnableContract) Initialize(ctx context.ContextInterface) error {
-	var err error
+// MessageVersion returns the message version clients should sign with
+func (ownable *OwnableContract) MessageVersion(ctx context.ContextInterface) (uint8, error) {
+	return ownable.hooks.MessageVersion(ctx)
+}
 
-	initOwner := ctx.Operator().String()
+// Initialize will set the caller as the owner
+func (ownable *OwnableContract) Initialize(ctx context.ContextInterface, msg context.Message) error {
+	initOwner, err := ctx.Caller()
+	if err != nil {
+		return err
+	}
 
 	if err := ownable.initializable.TryInitialize(ctx, OwnableInitializedKey); err != nil {
 		return err
 	}
 
-	if err = transferOwnership(ctx, library.ZeroAddress, library.Address(initOwner)); err != nil {
+	if err = transferOwnership(ctx, library.ZeroAddress, initOwner); err != nil {
 		return err
 	}
 
@@ -76,7 +111,7 @@ func (ownable *OwnableContract) Owner(ctx context.ContextInterface) (string, err
 }
 
 func owner(ctx context.ContextInterface) (library.Address, error) {
-	owner, err := ctx.GetStub().GetState(OwnerKey)
+	owner, err := ctx.State().Get(OwnerKey)
 	if err != nil {
 		return library.ZeroAddress, err
 	}
@@ -87,7 +122,7 @@ func owner(ctx context.ContextInterface) (library.Address, error) {
 	if err := addr.Validate(); err != nil {
 		return library.ZeroAddress, err
 	}
-	return addr, nil
+	return addr.Canonical(), nil
 }
 
 func onlyOwner(ctx context.ContextInterface) error {
@@ -96,7 +131,11 @@ func onlyOwner(ctx context.ContextInterface) error {
 		return err
 	}
 
-	if currOwner != ctx.Operator() {
+	caller, err := ctx.Caller()
+	if err != nil {
+		return err
+	}
+	if !currOwner.Equal(caller) {
 		return errors.New("Ownable: caller is not the owner")
 	}
 
@@ -105,7 +144,7 @@ func onlyOwner(ctx context.ContextInterface) error {
 
 // RenounceOwnership will reset owner to ZeroAddress
 // - only current Owner has this permission
-func (ownable *OwnableContract) RenounceOwnership(ctx context.ContextInterface) error {
+func (ownable *OwnableContract) RenounceOwnership(ctx context.ContextInterface, msg context.Message) error {
 	var err error
 
 	if err = onlyOwner(ctx); err != nil {
@@ -113,43 +152,171 @@ func (ownable *OwnableContract) RenounceOwnership(ctx context.ContextInterface)
 	}
 
 	previousOwner, _ := owner(ctx)
-	if err = transferOwnership(ctx, previousOwner, library.ZeroAddress); err != nil {
+	if err = setOwner(ctx, previousOwner, library.ZeroAddress); err != nil {
 		return err
 	}
 
-	return nil
+	// A nominated owner can not accept the renounced ownership
+	return ctx.State().Delete(PendingOwnerKey)
 }
 
-// TransferOwnership will transfer ownership to newOwner
+// TransferOwnership nominates newOwner,who becomes the owner after calling AcceptOwnership
 // - only current Owner has this permission
-func (ownable *OwnableContract) TransferOwnership(ctx context.ContextInterface, newOwner string) error {
-	var err error
+// - it replaces the previous nomination
+func (ownable *OwnableContract) TransferOwnership(ctx context.ContextInterface, msg context.Message, newOwner string) error {
+	return ownable.TransferOwnershipUntil(ctx, msg, newOwner, 0)
+}
+
+// TransferOwnershipUntil nominates newOwner who must accept the ownership until deadline(unix timestamp in seconds).
+// A zero deadline never expires.
+// - only current Owner has this permission
+// - it replaces the previous nomination
+func (ownable *OwnableContract) TransferOwnershipUntil(ctx context.ContextInterface, msg context.Message, newOwner string, deadline int64) error {
+	newOwnerAddr, err := library.ParseAddress(newOwner)
+	if err != nil {
+		return err
+	}
+	if err = newOwnerAddr.Validate(); err != nil {
+		return err
+	}
 
 	if err = onlyOwner(ctx); err != nil {
 		return err
 	}
 
-	previousOwner, _ := owner(ctx)
-	if err = transferOwnership(ctx, previousOwner, library.Address(newOwner)); err != nil {
+	if deadline != 0 {
+		txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+		if err != nil {
+			return err
+		}
+		if deadline <= txTimestamp.GetSeconds() {
+			return errors.Wrapf(ErrOwnershipTransferExpired, "deadline %d has passed", deadline)
+		}
+	}
+
+	bytes, err := json.Marshal(&PendingOwnership{
+		PendingOwner: newOwnerAddr,
+		Deadline:     deadline,
+	})
+	if err != nil {
 		return err
 	}
-	return nil
+	if err = ctx.State().Put(PendingOwnerKey, bytes); err != nil {
+		return err
+	}
+
+	currOwner, _ := owner(ctx)
+	return ctx.EmitEvent("OwnershipTransferStarted", &EventOwnershipTransferStarted{
+		PreviousOwner: currOwner,
+		NewOwner:      newOwnerAddr,
+		Deadline:      deadline,
+	})
 }
 
-func transferOwnership(ctx context.ContextInterface, previousOwner library.Address, newOwner library.Address) error {
+// PendingOwner returns the address of the nominated owner
+// - ZeroAddress will be returned if no owner is nominated
+func (ownable *OwnableContract) PendingOwner(ctx context.ContextInterface) (string, error) {
+	pending, err := pendingOwnership(ctx)
+	if err != nil {
+		return library.ZeroAddress.String(), err
+	}
+	if pending == nil {
+		return library.ZeroAddress.String(), nil
+	}
+	return pending.PendingOwner.String(), nil
+}
+
+func pendingOwnership(ctx context.ContextInterface) (*PendingOwnership, error) {
+	bytes, err := ctx.State().Get(PendingOwnerKey)
+	if err != nil {
+		return nil, err
+	}
+	if bytes == nil {
+		return nil, nil
+	}
+	pending := new(PendingOwnership)
+	if err = json.Unmarshal(bytes, pending); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// AcceptOwnership transfers the ownership to the nominated owner
+// - only the nominated owner has this permission before the deadline
+func (ownable *OwnableContract) AcceptOwnership(ctx context.ContextInterface, msg context.Message) error {
+	pending, err := pendingOwnership(ctx)
+	if err != nil {
+		return err
+	}
+	if pending == nil {
+		return ErrNoPendingOwner
+	}
+	caller, err := ctx.Caller()
+	if err != nil {
+		return err
+	}
+	if !pending.PendingOwner.Equal(caller) {
+		return ErrNotPendingOwner
+	}
+	if pending.Deadline != 0 {
+		txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+		if err != nil {
+			return err
+		}
+		if txTimestamp.GetSeconds() > pending.Deadline {
+			return errors.Wrapf(ErrOwnershipTransferExpired, "expired at %d", pending.Deadline)
+		}
+	}
+
+	if err = ctx.State().Delete(PendingOwnerKey); err != nil {
+		return err
+	}
+	previousOwner, _ := owner(ctx)
+	return transferOwnership(ctx, previousOwner, pending.PendingOwner)
+}
+
+// CancelOwnershipTransfer cancels the nomination of the pending owner
+// - only current Owner has this permission
+func (ownable *OwnableContract) CancelOwnershipTransfer(ctx context.ContextInterface, msg context.Message) error {
 	var err error
 
-	if err = newOwner.Validate(); err != nil {
+	if err = onlyOwner(ctx); err != nil {
 		return err
 	}
-	if err = ctx.GetStub().PutState(OwnerKey, newOwner.Bytes()); err != nil {
+
+	pending, err := pendingOwnership(ctx)
+	if err != nil {
 		return err
 	}
+	if pending == nil {
+		return ErrNoPendingOwner
+	}
+	if err = ctx.State().Delete(PendingOwnerKey); err != nil {
+		return err
+	}
+
+	currOwner, _ := owner(ctx)
+	return ctx.EmitEvent("OwnershipTransferCanceled", &EventOwnershipTransferCanceled{
+		Owner:        currOwner,
+		PendingOwner: pending.PendingOwner,
+	})
+}
 
-	ctx.EmitEvent("OwnershipTransferred", &EventOwnershipTransferred{
+func transferOwnership(ctx context.ContextInterface, previousOwner library.Address, newOwner library.Address) error {
+	if err := newOwner.Validate(); err != nil {
+		return err
+	}
+	return setOwner(ctx, previousOwner, newOwner)
+}
+
+// setOwner sets the owner without validating it,so the ownership can be renounced to ZeroAddress
+func setOwner(ctx context.ContextInterface, previousOwner library.Address, newOwner library.Address) error {
+	if err := ctx.State().Put(OwnerKey, newOwner.Bytes()); err != nil {
+		return err
+	}
+
+	return ctx.EmitEvent("OwnershipTransferred", &EventOwnershipTransferred{
 		PreviousOwner: previousOwner,
 		NewOwner:      newOwner,
 	})
-
-	return nil
 }
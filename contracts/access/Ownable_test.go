@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package access
+
+import (
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/contracts/nonce"
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context/contexttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newOwnedContract returns a chaincode at timestamp 1000 of an OwnableContract owned by alice
+func newOwnedContract(t *testing.T, alice *contexttest.Account) *contexttest.Chaincode {
+	cc := contexttest.NewChaincode(t, "ownable", NewOwnableContract(nonce.NewNonceContract(), nil))
+	cc.Now = 1000
+	assert.NoError(t, cc.Submit(alice, "Initialize"))
+	return cc
+}
+
+// assertOwners asserts the owner and the pending owner
+func assertOwners(t *testing.T, cc *contexttest.Chaincode, owner library.Address, pendingOwner library.Address) {
+	curr, err := cc.Invoke(nil, "Owner")
+	assert.NoError(t, err)
+	assert.Equal(t, owner.String(), curr)
+	pending, err := cc.Invoke(nil, "PendingOwner")
+	assert.NoError(t, err)
+	assert.Equal(t, pendingOwner.String(), pending)
+}
+
+func TestOwnable(t *testing.T) {
+	alice, bob, carol := newAccounts(t)
+
+	t.Run("Nominate then accept", func(t *testing.T) {
+		cc := newOwnedContract(t, alice)
+		assert.NoError(t, cc.Submit(alice, "TransferOwnership", bob.String()))
+		assert.Equal(t, "OwnershipTransferStarted", cc.Events()[0].Name)
+		// the owner does not change until the nominee accepts
+		assertOwners(t, cc, alice.Address, bob.Address)
+
+		assert.NoError(t, cc.Submit(bob, "AcceptOwnership"))
+		assert.Equal(t, "OwnershipTransferred", cc.Events()[0].Name)
+		assertOwners(t, cc, bob.Address, library.ZeroAddress)
+		assert.ErrorIs(t, cc.Submit(bob, "AcceptOwnership"), ErrNoPendingOwner)
+	})
+
+	t.Run("Only the nominee accepts", func(t *testing.T) {
+		cc := newOwnedContract(t, alice)
+		assert.NoError(t, cc.Submit(alice, "TransferOwnership", bob.String()))
+		assert.ErrorIs(t, cc.Submit(carol, "AcceptOwnership"), ErrNotPendingOwner)
+		assert.ErrorIs(t, cc.Submit(alice, "AcceptOwnership"), ErrNotPendingOwner)
+		assertOwners(t, cc, alice.Address, bob.Address)
+
+		// only the owner nominates
+		assert.Error(t, cc.Submit(carol, "TransferOwnership", carol.String()))
+	})
+
+	t.Run("Accept after the deadline", func(t *testing.T) {
+		cc := newOwnedContract(t, alice)
+		assert.NoError(t, cc.Submit(alice, "TransferOwnershipUntil", bob.String(), "1100"))
+
+		cc.Now = 1101
+		assert.ErrorIs(t, cc.Submit(bob, "AcceptOwnership"), ErrOwnershipTransferExpired)
+		assertOwners(t, cc, alice.Address, bob.Address)
+
+		// the deadline itself is still in time
+		cc.Now = 1100
+		assert.NoError(t, cc.Submit(bob, "AcceptOwnership"))
+		assertOwners(t, cc, bob.Address, library.ZeroAddress)
+	})
+
+	t.Run("Past deadline", func(t *testing.T) {
+		cc := newOwnedContract(t, alice)
+		assert.ErrorIs(t, cc.Submit(alice, "TransferOwnershipUntil", bob.String(), "1000"), ErrOwnershipTransferExpired)
+		assert.ErrorIs(t, cc.Submit(alice, "TransferOwnershipUntil", bob.String(), "1"), ErrOwnershipTransferExpired)
+		assertOwners(t, cc, alice.Address, library.ZeroAddress)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		cc := newOwnedContract(t, alice)
+		assert.ErrorIs(t, cc.Submit(alice, "CancelOwnershipTransfer"), ErrNoPendingOwner)
+		assert.NoError(t, cc.Submit(alice, "TransferOwnership", bob.String()))
+
+		// only the owner cancels
+		assert.Error(t, cc.Submit(bob, "CancelOwnershipTransfer"))
+		assert.NoError(t, cc.Submit(alice, "CancelOwnershipTransfer"))
+		assert.Equal(t, "OwnershipTransferCanceled", cc.Events()[0].Name)
+		assertOwners(t, cc, alice.Address, library.ZeroAddress)
+		assert.ErrorIs(t, cc.Submit(bob, "AcceptOwnership"), ErrNoPendingOwner)
+	})
+
+	t.Run("A nomination replaces the previous one", func(t *testing.T) {
+		cc := newOwnedContract(t, alice)
+		assert.NoError(t, cc.Submit(alice, "TransferOwnershipUntil", bob.String(), "1100"))
+		assert.NoError(t, cc.Submit(alice, "TransferOwnership", carol.String()))
+		assertOwners(t, cc, alice.Address, carol.Address)
+
+		// the replaced nomination has no deadline
+		cc.Now = 1101
+		assert.ErrorIs(t, cc.Submit(bob, "AcceptOwnership"), ErrNotPendingOwner)
+		assert.NoError(t, cc.Submit(carol, "AcceptOwnership"))
+		assertOwners(t, cc, carol.Address, library.ZeroAddress)
+	})
+
+	t.Run("Every step emits its event", func(t *testing.T) {
+		cc := newOwnedContract(t, alice)
+		assertEvent := func(name string, payload string) {
+			events := cc.Events()
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, name, events[0].Name)
+				assert.JSONEq(t, payload, string(events[0].Payload))
+			}
+		}
+
+		assert.NoError(t, cc.Submit(alice, "TransferOwnershipUntil", bob.String(), "1100"))
+		assertEvent("OwnershipTransferStarted", `{"PreviousOwner":"`+alice.String()+`","NewOwner":"`+bob.String()+`","Deadline":1100}`)
+		assert.NoError(t, cc.Submit(alice, "CancelOwnershipTransfer"))
+		assertEvent("OwnershipTransferCanceled", `{"Owner":"`+alice.String()+`","PendingOwner":"`+bob.String()+`"}`)
+		assert.NoError(t, cc.Submit(alice, "TransferOwnership", bob.String()))
+		assertEvent("OwnershipTransferStarted", `{"PreviousOwner":"`+alice.String()+`","NewOwner":"`+bob.String()+`","Deadline":0}`)
+		assert.NoError(t, cc.Submit(bob, "AcceptOwnership"))
+		assertEvent("OwnershipTransferred", `{"PreviousOwner":"`+alice.String()+`","NewOwner":"`+bob.String()+`"}`)
+		assert.NoError(t, cc.Submit(bob, "RenounceOwnership"))
+		assertEvent("OwnershipTransferred", `{"PreviousOwner":"`+bob.String()+`","NewOwner":"`+library.ZeroAddress.String()+`"}`)
+	})
+
+	t.Run("Renounce clears the pending owner", func(t *testing.T) {
+		cc := newOwnedContract(t, alice)
+		assert.NoError(t, cc.Submit(alice, "TransferOwnership", bob.String()))
+
+		assert.Error(t, cc.Submit(bob, "RenounceOwnership"))
+		assert.NoError(t, cc.Submit(alice, "RenounceOwnership"))
+		assertOwners(t, cc, library.ZeroAddress, library.ZeroAddress)
+		assert.ErrorIs(t, cc.Submit(bob, "AcceptOwnership"), ErrNoPendingOwner)
+
+		// nobody owns the contract any more
+		assert.Error(t, cc.Submit(alice, "TransferOwnership", bob.String()))
+	})
+}
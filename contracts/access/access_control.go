@@ -17,19 +17,27 @@ limitations under the License.
 package access
 
 import (
+	"encoding/hex"
+	"strconv"
+
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/sha3"
 
+	"github.com/bestchains/bestchains-contracts/contracts/nonce"
 	"github.com/bestchains/bestchains-contracts/library"
 	"github.com/bestchains/bestchains-contracts/library/context"
+	safemath "github.com/bestchains/bestchains-contracts/library/math"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
 const (
 	SuperAdminRole = "super~admin~role"
 
-	RoleAdminPrefix = "role~admin"
-	RolePrefix      = "role~account"
+	RoleAdminPrefix       = "role~admin"
+	RolePrefix            = "role~account"
+	AccountRolePrefix     = "account~role"
+	RoleMemberCountPrefix = "role~count"
+	RoleExpiryPrefix      = "role~expiry"
 )
 
 var (
@@ -38,79 +46,128 @@ var (
 
 var (
 	ErrRoleNotFound = errors.New("role not found")
+	ErrRoleExpired  = errors.New("role expired")
 )
 
 var _ IAccessControl = new(AccessControlContract)
 
+// RoleMembers is a page of the members of a role
+type RoleMembers struct {
+	Members []string `json:"members"`
+	// Bookmark is where the next page starts,empty if this is the last page
+	Bookmark string `json:"bookmark"`
+}
+
+// roleID encodes a role in hex,as a role like a hash is not a valid utf8 attribute of composite keys
+func roleID(role []byte) string {
+	return hex.EncodeToString(role)
+}
+
 // AccessControlContract implements IAccessControl
 type AccessControlContract struct {
 	contractapi.Contract
 	IOwnable
+
+	nonce.INonce
+
+	hooks *context.Hooks
 }
 
-func NewAccessControlContract(ownable IOwnable) *AccessControlContract {
+// NewAccessControlContract creates an AccessControlContract with the given nonce contract which is owned by ownable.
+func NewAccessControlContract(nonceContract nonce.INonce, ownable IOwnable, opts ...context.HookOption) *AccessControlContract {
 	accessControl := new(AccessControlContract)
 
 	accessControl.IOwnable = ownable
+	accessControl.INonce = nonceContract
 
 	accessControl.Name = "org.bestchains.com.AccessControlContract"
 	accessControl.TransactionContextHandler = new(context.Context)
-	accessControl.BeforeTransaction = context.BeforeTransaction
+	accessControl.hooks = context.NewHooks(opts...)
+	accessControl.hooks.Register(accessControl)
+	accessControl.BeforeTransaction = accessControl.hooks.BeforeTransaction
+	accessControl.AfterTransaction = accessControl.hooks.AfterTransaction
 
 	return accessControl
 }
 
-func (accessControl *AccessControlContract) Initialize(ctx context.ContextInterface) error {
+// MessageVersion returns the message version clients should sign with
+func (accessControl *AccessControlContract) MessageVersion(ctx context.ContextInterface) (uint8, error) {
+	return accessControl.hooks.MessageVersion(ctx)
+}
+
+func (accessControl *AccessControlContract) Initialize(ctx context.ContextInterface, msg context.Message) error {
 	var err error
 
-	if err = accessControl.IOwnable.Initialize(ctx); err != nil {
+	if err = accessControl.IOwnable.Initialize(ctx, msg); err != nil {
 		return errors.Wrap(err, "AccessControl: initialize")
 	}
 
-	if err = grantRole(ctx, HashedSuperAdminRole[:], ctx.Operator().String()); err != nil {
-		return errors.Wrap(err, "AccessControl: grant default role to operator")
+	caller, err := ctx.Caller()
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: caller")
+	}
+	if err = grantRole(ctx, HashedSuperAdminRole[:], caller.String(), 0); err != nil {
+		return errors.Wrap(err, "AccessControl: grant default role to caller")
+	}
+
+	if _, err = registerRole(ctx, SuperAdminRole, "default admin role"); err != nil {
+		return errors.Wrap(err, "AccessControl: register default role")
 	}
 
 	return nil
 }
 
-// SetRoleAdmin only when operator is the owner
+// SetRoleAdmin only when the caller has the default admin role
 // - only default role
+// - role and adminRole are registered names or hex hashes
 // - emit event `RoleAdminChanged`
-func (accessControl *AccessControlContract) SetRoleAdmin(ctx context.ContextInterface, role []byte, adminRole []byte) error {
-	var err error
+func (accessControl *AccessControlContract) SetRoleAdmin(ctx context.ContextInterface, msg context.Message, role string, adminRole string) error {
+	hashed, err := resolveRole(ctx, role)
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: invalid role")
+	}
+	admin, err := resolveRole(ctx, adminRole)
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: invalid adminRole")
+	}
 
-	if string(role) == "" || string(adminRole) == "" {
-		return errors.New("AccessControl: role and adminRole must not be empty")
+	if string(hashed) == string(admin) {
+		return errors.New("AccessControl: role and adminRole must not be the same")
 	}
 
-	if string(role) == string(adminRole) {
-		return errors.New("role and adminRole must not be the same")
+	caller, err := ctx.Caller()
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: caller")
 	}
 
 	// only default role
-	if err = hasRole(ctx, HashedSuperAdminRole[:], ctx.Operator()); err != nil {
+	if err = hasRole(ctx, HashedSuperAdminRole[:], caller); err != nil {
 		return errors.Wrap(err, "AccessControl: only default admin role")
 	}
 
+	return setRoleAdmin(ctx, hashed, admin)
+}
+
+// setRoleAdmin sets role's admin role and emits event `RoleAdminChanged`
+func setRoleAdmin(ctx context.ContextInterface, role []byte, adminRole []byte) error {
 	previousAdminRole, err := getRoleAdmin(ctx, role)
 	if err != nil {
 		return errors.Wrap(err, "AccessControl: create role's composite key")
 	}
 
-	roleAdminKey, err := ctx.GetStub().CreateCompositeKey(RoleAdminPrefix, []string{string(role)})
+	roleAdminKey, err := ctx.GetStub().CreateCompositeKey(RoleAdminPrefix, []string{roleID(role)})
 	if err != nil {
 		return errors.Wrap(err, "AccessControl: create role's composite key")
 	}
 
-	if err = ctx.GetStub().PutState(roleAdminKey, adminRole); err != nil {
+	if err = ctx.State().Put(roleAdminKey, adminRole); err != nil {
 		return errors.Wrap(err, "AccessControl: create role's composite key")
 	}
 
 	if err = ctx.EmitEvent("RoleAdminChanged", &EventRoleAdminChanged{
-		Role:              role,
-		PreviousAdminRole: previousAdminRole,
-		NewAdminRole:      adminRole,
+		Role:              roleID(role),
+		PreviousAdminRole: roleID(previousAdminRole),
+		NewAdminRole:      roleID(adminRole),
 	}); err != nil {
 		return errors.Wrap(err, "AccessControl: create role's composite key")
 	}
@@ -118,18 +175,28 @@ func (accessControl *AccessControlContract) SetRoleAdmin(ctx context.ContextInte
 	return nil
 }
 
-// GetRoleAdmin returns role's admin role
-func (accessControl *AccessControlContract) GetRoleAdmin(ctx context.ContextInterface, role []byte) ([]byte, error) {
-	return getRoleAdmin(ctx, role)
+// GetRoleAdmin returns role's admin role in hex
+// - role is a registered name or a hex hash
+// - empty will be returned if the admin role is not set
+func (accessControl *AccessControlContract) GetRoleAdmin(ctx context.ContextInterface, role string) (string, error) {
+	hashed, err := resolveRole(ctx, role)
+	if err != nil {
+		return "", errors.Wrap(err, "AccessControl: invalid role")
+	}
+	admin, err := getRoleAdmin(ctx, hashed)
+	if err != nil {
+		return "", err
+	}
+	return roleID(admin), nil
 }
 
 func getRoleAdmin(ctx context.ContextInterface, role []byte) ([]byte, error) {
-	roleAdminKey, err := ctx.GetStub().CreateCompositeKey(RoleAdminPrefix, []string{string(role)})
+	roleAdminKey, err := ctx.GetStub().CreateCompositeKey(RoleAdminPrefix, []string{roleID(role)})
 	if err != nil {
 		return nil, err
 	}
 
-	val, err := ctx.GetStub().GetState(roleAdminKey)
+	val, err := ctx.State().Get(roleAdminKey)
 	if err != nil {
 		return nil, err
 	}
@@ -154,21 +221,29 @@ func onlyRoleAdmin(ctx context.ContextInterface, role []byte, account library.Ad
 	return nil
 }
 
-// HasRole returns if account has been granted `role`
-func (accessControl *AccessControlContract) HasRole(ctx context.ContextInterface, role []byte, account string) (bool, error) {
-	if err := hasRole(ctx, role, library.Address(account)); err != nil {
+// HasRole returns if account has been granted `role`,which is a registered name or a hex hash
+func (accessControl *AccessControlContract) HasRole(ctx context.ContextInterface, role string, account string) (bool, error) {
+	addr, err := library.ParseAddress(account)
+	if err != nil {
+		return false, errors.Wrap(err, "AccessControl: invalid account")
+	}
+	hashed, err := resolveRole(ctx, role)
+	if err != nil {
+		return false, errors.Wrap(err, "AccessControl: invalid role")
+	}
+	if err = hasRole(ctx, hashed, addr); err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
 func hasRole(ctx context.ContextInterface, role []byte, account library.Address) error {
-	roleKey, err := ctx.GetStub().CreateCompositeKey(RolePrefix, []string{string(role), account.String()})
+	roleKey, err := ctx.State().Key(RolePrefix, roleID(role), account.String())
 	if err != nil {
 		return err
 	}
 
-	val, err := ctx.GetStub().GetState(roleKey)
+	val, err := ctx.State().Get(roleKey)
 	if err != nil {
 		return err
 	}
@@ -181,30 +256,127 @@ func hasRole(ctx context.ContextInterface, role []byte, account library.Address)
 		return errors.Errorf("AccessingControl: account %s is missing role %s", account, library.BytesToHexString(role))
 	}
 
+	expiry, expired, err := roleExpired(ctx, role, account)
+	if err != nil {
+		return err
+	}
+	if expired {
+		return errors.Wrapf(ErrRoleNotFound, "expired at %d", expiry)
+	}
+
 	return nil
 }
 
-// GrantRole grants `role` to `account` only when operator has `role`'s admin role
+// roleExpired returns the expiry of the role granted to account
+// and tells whether it is expired at the transaction timestamp
+func roleExpired(ctx context.ContextInterface, role []byte, account library.Address) (int64, bool, error) {
+	expiry, err := getRoleExpiry(ctx, role, account)
+	if err != nil {
+		return 0, false, err
+	}
+	if expiry == 0 {
+		return 0, false, nil
+	}
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, false, err
+	}
+	return expiry, txTimestamp.GetSeconds() > expiry, nil
+}
+
+// GetRoleExpiry returns the unix timestamp in seconds until which account has `role`
+// - 0 will be returned if the role never expires
+// - ErrRoleNotFound will be returned if the role is not granted
+// - role is a registered name or a hex hash
+func (accessControl *AccessControlContract) GetRoleExpiry(ctx context.ContextInterface, role string, account string) (int64, error) {
+	addr, err := library.ParseAddress(account)
+	if err != nil {
+		return 0, errors.Wrap(err, "AccessControl: invalid account")
+	}
+	hashed, err := resolveRole(ctx, role)
+	if err != nil {
+		return 0, errors.Wrap(err, "AccessControl: invalid role")
+	}
+
+	roleKey, err := ctx.State().Key(RolePrefix, roleID(hashed), addr.String())
+	if err != nil {
+		return 0, err
+	}
+	val, err := ctx.State().Get(roleKey)
+	if err != nil {
+		return 0, err
+	}
+	if val == nil {
+		return 0, ErrRoleNotFound
+	}
+
+	return getRoleExpiry(ctx, hashed, addr)
+}
+
+func getRoleExpiry(ctx context.ContextInterface, role []byte, account library.Address) (int64, error) {
+	val, err := ctx.State().GetString(RoleExpiryPrefix, roleID(role), account.String())
+	if err != nil {
+		return 0, err
+	}
+	if val == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(val, 10, 64)
+}
+
+// GrantRole grants `role` to `account` only when the caller has `role`'s admin role
+// - role is a registered name or a hex hash
 // - emit event `RoleGranted` if succ
-func (accessControl *AccessControlContract) GrantRole(ctx context.ContextInterface, role []byte, account string) error {
-	var err error
+func (accessControl *AccessControlContract) GrantRole(ctx context.ContextInterface, msg context.Message, role string, account string) error {
+	return accessControl.GrantRoleUntil(ctx, msg, role, account, 0)
+}
 
-	if err = library.Address(account).Validate(); err != nil {
+// GrantRoleUntil grants `role` to `account` until expiry(unix timestamp in seconds) only when the caller has `role`'s admin role
+// - the role never expires if expiry is 0
+// - a role granted before is extended or shortened to expiry
+// - emit event `RoleGranted` with the expiry if succ
+func (accessControl *AccessControlContract) GrantRoleUntil(ctx context.ContextInterface, msg context.Message, role string, account string, expiry int64) error {
+	addr, err := library.ParseAddress(account)
+	if err != nil {
 		return errors.Wrap(err, "AccessControl: invalid account")
 	}
+	if err = addr.Validate(); err != nil {
+		return errors.Wrap(err, "AccessControl: invalid account")
+	}
+	hashed, err := resolveRole(ctx, role)
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: invalid role")
+	}
 
-	if err = onlyRoleAdmin(ctx, role, ctx.Operator()); err != nil {
+	caller, err := ctx.Caller()
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: caller")
+	}
+
+	if err = onlyRoleAdmin(ctx, hashed, caller); err != nil {
 		return errors.Wrap(err, "AccessControl: onlyRoleAdmin")
 	}
 
-	if err = grantRole(ctx, role, account); err != nil {
+	if expiry != 0 {
+		txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+		if err != nil {
+			return err
+		}
+		if expiry <= txTimestamp.GetSeconds() {
+			return errors.Wrapf(ErrRoleExpired, "expiry %d has passed", expiry)
+		}
+	}
+
+	if err = grantRole(ctx, hashed, addr.String(), expiry); err != nil {
 		return errors.Wrap(err, "AccessControl: grantRole")
 	}
 
 	if err = ctx.EmitEvent("RoleGranted", &EventRoleGranted{
-		Role:    role,
-		Account: library.Address(account),
-		Sender:  ctx.Operator(),
+		Role:    roleID(hashed),
+		Account: addr,
+		Sender:  caller,
+		Expiry:  expiry,
 	}); err != nil {
 		return errors.Wrap(err, "AccessControl: event")
 	}
@@ -212,49 +384,139 @@ func (accessControl *AccessControlContract) GrantRole(ctx context.ContextInterfa
 	return nil
 }
 
-func grantRole(ctx context.ContextInterface, role []byte, account string) error {
-	roleKey, err := ctx.GetStub().CreateCompositeKey(RolePrefix, []string{string(role), account})
+// grantRole grants the role to the account until expiry which is 0 if the role never expires,
+// and keeps the index of the account's roles and the member count of the role.
+// They are kept in the state of the transaction,so a role granted twice in one transaction is counted once.
+// An expired grant is counted until it is revoked,so granting the role again to its account is not counted either.
+func grantRole(ctx context.ContextInterface, role []byte, account string, expiry int64) error {
+	roleKey, err := ctx.State().Key(RolePrefix, roleID(role), account)
+	if err != nil {
+		return err
+	}
+	accountRoleKey, err := ctx.State().Key(AccountRolePrefix, account, roleID(role))
+	if err != nil {
+		return err
+	}
+	expiryKey, err := ctx.State().Key(RoleExpiryPrefix, roleID(role), account)
 	if err != nil {
 		return err
 	}
 
-	if err = ctx.GetStub().PutState(roleKey, library.True.Bytes()); err != nil {
+	val, err := ctx.State().Get(roleKey)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		if err = updateRoleMemberCount(ctx, role, true); err != nil {
+			return err
+		}
+	}
+
+	if err = ctx.State().Put(roleKey, library.True.Bytes()); err != nil {
+		return err
+	}
+	if err = ctx.State().Put(accountRoleKey, library.True.Bytes()); err != nil {
+		return err
+	}
+	if expiry == 0 {
+		err = ctx.State().Delete(expiryKey)
+	} else {
+		err = ctx.State().Put(expiryKey, []byte(strconv.FormatInt(expiry, 10)))
+	}
+	if err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// Revoke grants `role` to `account` only when operator has `role`'s admin role
+// updateRoleMemberCount adds one member to the role if grow,otherwise removes one
+func updateRoleMemberCount(ctx context.ContextInterface, role []byte, grow bool) error {
+	count, err := ctx.State().GetUint64(RoleMemberCountPrefix, roleID(role))
+	if err != nil {
+		return err
+	}
+	var succ bool
+	if grow {
+		succ, count = safemath.TryAdd(count, 1)
+	} else {
+		succ, count = safemath.TrySub(count, 1)
+	}
+	if !succ {
+		return safemath.ErrMathOpOverflowed
+	}
+	return ctx.State().PutUint64(count, RoleMemberCountPrefix, roleID(role))
+}
+
+// Revoke grants `role` to `account` only when the caller has `role`'s admin role
+// - role is a registered name or a hex hash
 // - emit event `RoleRevoked` if succ
-func (accessControl *AccessControlContract) RevokeRole(ctx context.ContextInterface, role []byte, account string) error {
-	var err error
+func (accessControl *AccessControlContract) RevokeRole(ctx context.ContextInterface, msg context.Message, role string, account string) error {
+	addr, err := library.ParseAddress(account)
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: invalid account")
+	}
+	hashed, err := resolveRole(ctx, role)
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: invalid role")
+	}
 
-	if err = onlyRoleAdmin(ctx, role, ctx.Operator()); err != nil {
+	caller, err := ctx.Caller()
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: caller")
+	}
+
+	if err = onlyRoleAdmin(ctx, hashed, caller); err != nil {
 		return errors.Wrap(err, "AccessControl: onlyRoleAdmin")
 	}
 
-	if err = revokeRole(ctx, role, library.Address(account)); err != nil {
+	if err = revokeRole(ctx, hashed, addr); err != nil {
 		return errors.Wrap(err, "AccessControl: revokeRole")
 	}
 
 	return nil
 }
 
+// revokeRole revokes the role from the account and keeps the index of the account's roles and the member count of the role
 func revokeRole(ctx context.ContextInterface, role []byte, account library.Address) error {
-	roleKey, err := ctx.GetStub().CreateCompositeKey(RolePrefix, []string{string(role), account.String()})
+	roleKey, err := ctx.State().Key(RolePrefix, roleID(role), account.String())
+	if err != nil {
+		return err
+	}
+	accountRoleKey, err := ctx.State().Key(AccountRolePrefix, account.String(), roleID(role))
+	if err != nil {
+		return err
+	}
+	expiryKey, err := ctx.State().Key(RoleExpiryPrefix, roleID(role), account.String())
 	if err != nil {
 		return err
 	}
 
-	if err = ctx.GetStub().DelState(roleKey); err != nil {
+	val, err := ctx.State().Get(roleKey)
+	if err != nil {
+		return err
+	}
+	if val != nil {
+		if err = updateRoleMemberCount(ctx, role, false); err != nil {
+			return err
+		}
+	}
+
+	if err = ctx.State().Delete(roleKey); err != nil {
+		return err
+	}
+	if err = ctx.State().Delete(accountRoleKey); err != nil {
+		return err
+	}
+	if err = ctx.State().Delete(expiryKey); err != nil {
 		return err
 	}
 
+	sender, _ := ctx.Caller()
 	if err = ctx.EmitEvent("RoleRevoked", &EventRoleRevoked{
-		Role:    role,
+		Role:    roleID(role),
 		Account: account,
-		Sender:  ctx.Operator(),
+		Sender:  sender,
 	}); err != nil {
 		return errors.Wrap(err, "AccessControl: event")
 	}
@@ -263,16 +525,121 @@ func revokeRole(ctx context.ContextInterface, role []byte, account library.Addre
 }
 
 // RenounceRole by account itself
-func (accessControl *AccessControlContract) RenounceRole(ctx context.ContextInterface, msg context.Message, role []byte, account string) error {
-	var err error
+// - role is a registered name or a hex hash
+func (accessControl *AccessControlContract) RenounceRole(ctx context.ContextInterface, msg context.Message, role string, account string) error {
+	addr, err := library.ParseAddress(account)
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: invalid account")
+	}
+	hashed, err := resolveRole(ctx, role)
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: invalid role")
+	}
 
-	if ctx.MsgSender() != library.Address(account) {
+	caller, err := ctx.Caller()
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: caller")
+	}
+	if !caller.Equal(addr) {
 		return errors.New("AccessControl: can only renounce roles for self")
 	}
 
-	if err = revokeRole(ctx, role, library.Address(account)); err != nil {
+	if err = revokeRole(ctx, hashed, addr); err != nil {
 		return errors.Wrap(err, "AccessControl: revokeRole")
 	}
 
 	return nil
 }
+
+// GetRoleMembers returns a page of the accounts which have the role,
+// starting from bookmark which is empty for the first page
+// - role is a registered name or a hex hash
+// - accounts whose role is expired are left out,so a page can have less than pageSize members before the last page
+func (accessControl *AccessControlContract) GetRoleMembers(ctx context.ContextInterface, role string, pageSize int32, bookmark string) (*RoleMembers, error) {
+	hashed, err := resolveRole(ctx, role)
+	if err != nil {
+		return nil, errors.Wrap(err, "AccessControl: invalid role")
+	}
+
+	iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(RolePrefix, []string{roleID(hashed)}, pageSize, bookmark)
+	if err != nil {
+		return nil, errors.Wrap(err, "AccessControl: get role members")
+	}
+	defer iterator.Close()
+
+	members := &RoleMembers{Members: []string{}}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, errors.Wrap(err, "AccessControl: get role members")
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(kv.GetKey())
+		if err != nil {
+			return nil, errors.Wrap(err, "AccessControl: get role members")
+		}
+		_, expired, err := roleExpired(ctx, hashed, library.Address(attributes[1]))
+		if err != nil {
+			return nil, errors.Wrap(err, "AccessControl: get role members")
+		}
+		if expired {
+			continue
+		}
+		members.Members = append(members.Members, attributes[1])
+	}
+	if metadata.GetFetchedRecordsCount() == pageSize {
+		members.Bookmark = metadata.GetBookmark()
+	}
+
+	return members, nil
+}
+
+// GetRoleMemberCount returns the number of accounts which have been granted the role
+// - role is a registered name or a hex hash
+// - expired grants are counted until they are revoked,as nothing happens on the ledger when a role expires
+func (accessControl *AccessControlContract) GetRoleMemberCount(ctx context.ContextInterface, role string) (uint64, error) {
+	hashed, err := resolveRole(ctx, role)
+	if err != nil {
+		return 0, errors.Wrap(err, "AccessControl: invalid role")
+	}
+	return ctx.State().GetUint64(RoleMemberCountPrefix, roleID(hashed))
+}
+
+// GetAccountRoles returns the roles of the account in hex,leaving out the expired ones
+func (accessControl *AccessControlContract) GetAccountRoles(ctx context.ContextInterface, account string) ([]string, error) {
+	addr, err := library.ParseAddress(account)
+	if err != nil {
+		return nil, errors.Wrap(err, "AccessControl: invalid account")
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(AccountRolePrefix, []string{addr.String()})
+	if err != nil {
+		return nil, errors.Wrap(err, "AccessControl: get account roles")
+	}
+	defer iterator.Close()
+
+	roles := []string{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, errors.Wrap(err, "AccessControl: get account roles")
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(kv.GetKey())
+		if err != nil {
+			return nil, errors.Wrap(err, "AccessControl: get account roles")
+		}
+		role, err := hex.DecodeString(attributes[1])
+		if err != nil {
+			return nil, errors.Wrap(err, "AccessControl: get account roles")
+		}
+		_, expired, err := roleExpired(ctx, role, addr)
+		if err != nil {
+			return nil, errors.Wrap(err, "AccessControl: get account roles")
+		}
+		if expired {
+			continue
+		}
+		roles = append(roles, attributes[1])
+	}
+
+	return roles, nil
+}
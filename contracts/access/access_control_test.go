@@ -0,0 +1,285 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package access
+
+import (
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/contracts/nonce"
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/context/contexttest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newAccounts returns the accounts of a test,of which alice initializes the contracts
+func newAccounts(t *testing.T) (alice *contexttest.Account, bob *contexttest.Account, carol *contexttest.Account) {
+	return contexttest.NewAccount(t), contexttest.NewAccount(t), contexttest.NewAccount(t)
+}
+
+// newAccessControl returns a chaincode at timestamp 1000 of an AccessControlContract initialized by alice
+func newAccessControl(t *testing.T, alice *contexttest.Account, opts ...context.HookOption) *contexttest.Chaincode {
+	nonceContract := nonce.NewNonceContract()
+	cc := contexttest.NewChaincode(t, "access", NewAccessControlContract(nonceContract, NewOwnableContract(nonceContract, nil, opts...), opts...))
+	cc.Now = 1000
+	assert.NoError(t, cc.Submit(alice, "Initialize"))
+	return cc
+}
+
+// assertRole asserts whether account has role
+func assertRole(t *testing.T, cc *contexttest.Chaincode, role string, account *contexttest.Account, expected bool) {
+	has, err := cc.Invoke(nil, "HasRole", role, account.String())
+	if !expected {
+		assert.ErrorIs(t, err, ErrRoleNotFound)
+		return
+	}
+	assert.NoError(t, err)
+	assert.Equal(t, true, has)
+}
+
+func TestRoleMemberCount(t *testing.T) {
+	alice, bob, _ := newAccounts(t)
+	cc := newAccessControl(t, alice)
+	role := HashRole("minter")
+	hexRole := library.BytesToHexString(role)
+
+	// members granted in one transaction are counted once
+	assert.NoError(t, cc.Run(alice, "", false, nil, func(ctx *context.Context, _ *context.Message) error {
+		assert.NoError(t, grantRole(ctx, role, alice.String(), 0))
+		assert.NoError(t, grantRole(ctx, role, alice.String(), 0))
+		return grantRole(ctx, role, bob.String(), 1100)
+	}))
+	count, err := cc.Invoke(nil, "GetRoleMemberCount", hexRole)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+	expiry, err := cc.Invoke(nil, "GetRoleExpiry", hexRole, bob.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1100), expiry)
+
+	// members revoked in one transaction are counted once
+	assert.NoError(t, cc.Run(alice, "", false, nil, func(ctx *context.Context, _ *context.Message) error {
+		assert.NoError(t, revokeRole(ctx, role, bob.Address))
+		return revokeRole(ctx, role, bob.Address)
+	}))
+	count, err = cc.Invoke(nil, "GetRoleMemberCount", hexRole)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+	_, err = cc.Invoke(nil, "GetRoleExpiry", hexRole, bob.String())
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+}
+
+func TestRoleMembers(t *testing.T) {
+	alice, bob, _ := newAccounts(t)
+	cc := newAccessControl(t, alice)
+	assert.NoError(t, cc.Submit(alice, "RegisterRole", "minter", "mints tokens", SuperAdminRole))
+	assert.NoError(t, cc.Submit(alice, "RegisterRole", "burner", "burns tokens", SuperAdminRole))
+	hexMinter := library.BytesToHexString(HashRole("minter"))
+	hexBurner := library.BytesToHexString(HashRole("burner"))
+
+	minters := []string{bob.String()}
+	for i := 0; i < 4; i++ {
+		minters = append(minters, contexttest.NewAccount(t).String())
+	}
+	for _, minter := range minters {
+		assert.NoError(t, cc.Submit(alice, "GrantRole", "minter", minter))
+	}
+	assert.NoError(t, cc.Submit(alice, "GrantRole", "burner", bob.String()))
+
+	// listMembers lists the members of the role page by page
+	listMembers := func(role string) []string {
+		var members []string
+		bookmark := ""
+		for pages := 0; ; pages++ {
+			page, err := cc.Invoke(nil, "GetRoleMembers", role, "2", bookmark)
+			assert.NoError(t, err)
+			assert.LessOrEqual(t, len(page.(*RoleMembers).Members), 2)
+			members = append(members, page.(*RoleMembers).Members...)
+			bookmark = page.(*RoleMembers).Bookmark
+			if bookmark == "" || pages > len(minters) {
+				return members
+			}
+		}
+	}
+
+	assert.ElementsMatch(t, minters, listMembers("minter"))
+	assert.Equal(t, []string{bob.String()}, listMembers(hexBurner))
+	count, err := cc.Invoke(nil, "GetRoleMemberCount", "minter")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), count)
+	roles, err := cc.Invoke(nil, "GetAccountRoles", bob.String())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{hexMinter, hexBurner}, roles)
+
+	// revoking a role updates both indexes
+	assert.NoError(t, cc.Submit(alice, "RevokeRole", "minter", bob.String()))
+	assert.ElementsMatch(t, minters[1:], listMembers("minter"))
+	count, err = cc.Invoke(nil, "GetRoleMemberCount", "minter")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), count)
+	roles, err = cc.Invoke(nil, "GetAccountRoles", bob.String())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{hexBurner}, roles)
+
+	// an account without roles has an empty list
+	roles, err = cc.Invoke(nil, "GetAccountRoles", contexttest.NewAccount(t).String())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, roles)
+}
+
+func TestRoleExpiry(t *testing.T) {
+	alice, bob, carol := newAccounts(t)
+	cc := newAccessControl(t, alice)
+	assert.NoError(t, cc.Submit(alice, "RegisterRole", "minter", "mints tokens", SuperAdminRole))
+	hexRole := library.BytesToHexString(HashRole("minter"))
+
+	// assertMembers asserts the unexpired members of the role and the roles of bob
+	assertMembers := func(members []string, bobRoles []string) {
+		page, err := cc.Invoke(nil, "GetRoleMembers", hexRole, "10", "")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, members, page.(*RoleMembers).Members)
+		roles, err := cc.Invoke(nil, "GetAccountRoles", bob.String())
+		assert.NoError(t, err)
+		assert.Equal(t, bobRoles, roles)
+	}
+
+	t.Run("Past expiry", func(t *testing.T) {
+		assert.ErrorIs(t, cc.Submit(alice, "GrantRoleUntil", hexRole, bob.String(), "1000"), ErrRoleExpired)
+		assert.ErrorIs(t, cc.Submit(alice, "GrantRoleUntil", hexRole, bob.String(), "1"), ErrRoleExpired)
+		_, err := cc.Invoke(nil, "GetRoleExpiry", hexRole, bob.String())
+		assert.ErrorIs(t, err, ErrRoleNotFound)
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		assert.NoError(t, cc.Submit(alice, "GrantRoleUntil", hexRole, bob.String(), "1100"))
+		assert.Equal(t, "RoleGranted", cc.Events()[0].Name)
+		assert.Contains(t, string(cc.Events()[0].Payload), `"Expiry":1100`)
+		assert.NoError(t, cc.Submit(alice, "GrantRole", hexRole, carol.String()))
+
+		expiry, err := cc.Invoke(nil, "GetRoleExpiry", hexRole, bob.String())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1100), expiry)
+		expiry, err = cc.Invoke(nil, "GetRoleExpiry", hexRole, carol.String())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), expiry)
+
+		assertRole(t, cc, hexRole, bob, true)
+		assertMembers([]string{bob.String(), carol.String()}, []string{hexRole})
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		cc.Now = 1101
+		assertRole(t, cc, hexRole, bob, false)
+		assertRole(t, cc, hexRole, carol, true)
+		assertMembers([]string{carol.String()}, []string{})
+
+		// the expired grant is counted until it is revoked
+		count, err := cc.Invoke(nil, "GetRoleMemberCount", hexRole)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2), count)
+	})
+
+	t.Run("Granted again after expiry", func(t *testing.T) {
+		assert.NoError(t, cc.Submit(alice, "GrantRoleUntil", hexRole, bob.String(), "1200"))
+
+		assertRole(t, cc, hexRole, bob, true)
+		assertMembers([]string{bob.String(), carol.String()}, []string{hexRole})
+		count, err := cc.Invoke(nil, "GetRoleMemberCount", hexRole)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2), count)
+	})
+}
+
+func TestExpiredGrants(t *testing.T) {
+	alice, bob, carol := newAccounts(t)
+	cc := newAccessControl(t, alice)
+	assert.NoError(t, cc.Submit(alice, "RegisterRole", "operator", "operates minters", SuperAdminRole))
+	assert.NoError(t, cc.Submit(alice, "RegisterRole", "minter", "mints tokens", "operator"))
+
+	t.Run("An expired admin role grants nothing", func(t *testing.T) {
+		assert.NoError(t, cc.Submit(alice, "GrantRoleUntil", "operator", bob.String(), "1100"))
+		assert.NoError(t, cc.Submit(bob, "GrantRoleUntil", "minter", carol.String(), "1200"))
+
+		cc.Now = 1101
+		assert.Error(t, cc.Submit(bob, "GrantRole", "minter", alice.String()))
+		assert.Error(t, cc.Submit(bob, "RevokeRole", "minter", carol.String()))
+		// the roles it granted do not expire with it
+		assertRole(t, cc, "minter", carol, true)
+		assertRole(t, cc, "minter", alice, false)
+	})
+
+	t.Run("GrantRole makes a role permanent", func(t *testing.T) {
+		assert.NoError(t, cc.Submit(alice, "GrantRole", "operator", bob.String()))
+		assert.NoError(t, cc.Submit(bob, "GrantRole", "minter", carol.String()))
+		expiry, err := cc.Invoke(nil, "GetRoleExpiry", "minter", carol.String())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), expiry)
+
+		cc.Now = 1201
+		assertRole(t, cc, "minter", carol, true)
+	})
+
+	t.Run("An expired role is revoked and renounced as usual", func(t *testing.T) {
+		assert.NoError(t, cc.Submit(bob, "GrantRoleUntil", "minter", alice.String(), "1300"))
+		assert.NoError(t, cc.Submit(bob, "GrantRoleUntil", "minter", carol.String(), "1300"))
+		cc.Now = 1301
+		count, err := cc.Invoke(nil, "GetRoleMemberCount", "minter")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2), count)
+
+		assert.NoError(t, cc.Submit(alice, "RenounceRole", "minter", alice.String()))
+		assert.Equal(t, "RoleRevoked", cc.Events()[0].Name)
+		assert.NoError(t, cc.Submit(bob, "RevokeRole", "minter", carol.String()))
+		count, err = cc.Invoke(nil, "GetRoleMemberCount", "minter")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), count)
+		_, err = cc.Invoke(nil, "GetRoleExpiry", "minter", carol.String())
+		assert.ErrorIs(t, err, ErrRoleNotFound)
+	})
+}
+
+func TestSignedAdministration(t *testing.T) {
+	alice, bob, _ := newAccounts(t)
+	// only the signed messages identify the callers
+	policy := context.WithIdentityPolicy(context.IdentitySender)
+	nonceContract := nonce.NewNonceContract()
+	cc := contexttest.NewChaincode(t, "access", NewAccessControlContract(nonceContract, NewOwnableContract(nonceContract, nil, policy), policy))
+
+	assert.NoError(t, cc.Submit(alice, "Initialize"))
+	owner, err := cc.Invoke(nil, "Owner")
+	assert.NoError(t, err)
+	assert.Equal(t, alice.String(), owner)
+
+	assert.NoError(t, cc.Submit(alice, "RegisterRole", "role~client", "client", SuperAdminRole))
+	assert.NoError(t, cc.Submit(alice, "GrantRole", "role~client", bob.String()))
+	assertRole(t, cc, "role~client", bob, true)
+	assert.NoError(t, cc.Submit(alice, "RevokeRole", "role~client", bob.String()))
+	assertRole(t, cc, "role~client", bob, false)
+
+	assert.NoError(t, cc.Submit(alice, "TransferOwnership", bob.String()))
+	assert.NoError(t, cc.Submit(bob, "AcceptOwnership"))
+	owner, err = cc.Invoke(nil, "Owner")
+	assert.NoError(t, err)
+	assert.Equal(t, bob.String(), owner)
+
+	// every signed call uses a nonce of its sender,which clients read from the same contract
+	nonces, err := cc.Invoke(nil, "Current", alice.String())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), nonces)
+	nonces, err = cc.Invoke(nil, "Current", bob.String())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), nonces)
+}
@@ -27,31 +27,58 @@ type EventOwnershipTransferred struct {
 	NewOwner      library.Address
 }
 
+// EventOwnershipTransferStarted emit when a new owner is nominated
+type EventOwnershipTransferStarted struct {
+	PreviousOwner library.Address
+	NewOwner      library.Address
+	Deadline      int64
+}
+
+// EventOwnershipTransferCanceled emit when the nomination of the pending owner is canceled
+type EventOwnershipTransferCanceled struct {
+	Owner        library.Address
+	PendingOwner library.Address
+}
+
 // IOwnable defines the interfaces which ownable contract must implement
 type IOwnable interface {
-	Initialize(ctx context.ContextInterface) error
+	Initialize(ctx context.ContextInterface, msg context.Message) error
 	Owner(ctx context.ContextInterface) (string, error)
-	RenounceOwnership(ctx context.ContextInterface) error
-	TransferOwnership(ctx context.ContextInterface, newOwner string) error
+	PendingOwner(ctx context.ContextInterface) (string, error)
+	RenounceOwnership(ctx context.ContextInterface, msg context.Message) error
+	TransferOwnership(ctx context.ContextInterface, msg context.Message, newOwner string) error
+	TransferOwnershipUntil(ctx context.ContextInterface, msg context.Message, newOwner string, deadline int64) error
+	AcceptOwnership(ctx context.ContextInterface, msg context.Message) error
+	CancelOwnershipTransfer(ctx context.ContextInterface, msg context.Message) error
 }
 
-// EventRoleAdminChanged emit when role's admin role changed
+// EventRoleAdminChanged emit when role's admin role changed,with roles in hex
 type EventRoleAdminChanged struct {
-	Role              []byte
-	PreviousAdminRole []byte
-	NewAdminRole      []byte
+	Role              string
+	PreviousAdminRole string
+	NewAdminRole      string
+}
+
+// EventRoleRegistered emit when a role is registered with a name,with roles in hex
+type EventRoleRegistered struct {
+	Role        string
+	Name        string
+	Description string
+	AdminRole   string
 }
 
-// EventRoleGranted emit when a account granted a role
+// EventRoleGranted emit when a account granted a role in hex
 type EventRoleGranted struct {
-	Role    []byte
+	Role    string
 	Account library.Address
 	Sender  library.Address
+	// Expiry is the unix timestamp in seconds until which the role is granted,0 if the role never expires
+	Expiry int64
 }
 
-// EventRoleRevoked emit when a account's role got revoked
+// EventRoleRevoked emit when a account's role in hex got revoked
 type EventRoleRevoked struct {
-	Role    []byte
+	Role    string
 	Account library.Address
 	Sender  library.Address
 }
@@ -59,11 +86,20 @@ type EventRoleRevoked struct {
 // IAccessControl defines the interfaces which access control contract must implement
 type IAccessControl interface {
 	IOwnable
-	Initialize(ctx context.ContextInterface) error
-	SetRoleAdmin(ctx context.ContextInterface, role []byte, adminRole []byte) error
-	GetRoleAdmin(ctx context.ContextInterface, role []byte) ([]byte, error)
-	HasRole(ctx context.ContextInterface, role []byte, account string) (bool, error)
-	GrantRole(ctx context.ContextInterface, role []byte, account string) error
-	RevokeRole(ctx context.ContextInterface, role []byte, account string) error
-	RenounceRole(ctx context.ContextInterface, msg context.Message, role []byte, account string) error
+	Initialize(ctx context.ContextInterface, msg context.Message) error
+	MessageVersion(ctx context.ContextInterface) (uint8, error)
+	SetRoleAdmin(ctx context.ContextInterface, msg context.Message, role string, adminRole string) error
+	GetRoleAdmin(ctx context.ContextInterface, role string) (string, error)
+	RegisterRole(ctx context.ContextInterface, msg context.Message, name string, description string, adminRole string) error
+	RoleInfo(ctx context.ContextInterface, role string) (*RegisteredRole, error)
+	ListRoles(ctx context.ContextInterface) ([]RegisteredRole, error)
+	HasRole(ctx context.ContextInterface, role string, account string) (bool, error)
+	GrantRole(ctx context.ContextInterface, msg context.Message, role string, account string) error
+	GrantRoleUntil(ctx context.ContextInterface, msg context.Message, role string, account string, expiry int64) error
+	GetRoleExpiry(ctx context.ContextInterface, role string, account string) (int64, error)
+	RevokeRole(ctx context.ContextInterface, msg context.Message, role string, account string) error
+	RenounceRole(ctx context.ContextInterface, msg context.Message, role string, account string) error
+	GetRoleMembers(ctx context.ContextInterface, role string, pageSize int32, bookmark string) (*RoleMembers, error)
+	GetRoleMemberCount(ctx context.ContextInterface, role string) (uint64, error)
+	GetAccountRoles(ctx context.ContextInterface, account string) ([]string, error)
 }
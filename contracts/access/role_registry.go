@@ -0,0 +1,283 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package access
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/bestchains/bestchains-contracts/library/context"
+)
+
+const (
+	RoleInfoPrefix = "role~info"
+	RoleNamePrefix = "role~name"
+)
+
+// roleLength is the length of a role,which is a sha3-256 hash
+const roleLength = 32
+
+var (
+	ErrInvalidRoleName       = errors.New("invalid role name")
+	ErrRoleAlreadyRegistered = errors.New("role already registered")
+	ErrUnknownRole           = errors.New("unknown role")
+)
+
+// RegisteredRole is a role registered with a readable name
+type RegisteredRole struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Role is the hash of the name in hex
+	Role string `json:"role"`
+	// AdminRole is the current admin role in hex,empty if not set
+	AdminRole string `json:"adminRole"`
+	// AdminRoleName is the name of the admin role,empty if it is not registered
+	AdminRoleName string `json:"adminRoleName"`
+}
+
+// HashRole returns the role named `name`,which is the sha3 hash of the name like `HashedSuperAdminRole`
+func HashRole(name string) []byte {
+	role := sha3.Sum256([]byte(name))
+	return role[:]
+}
+
+// RegisterRole registers a role named `name` only when the caller has the default admin role
+// - the role is `HashRole(name)`,so registering `SuperAdminRole` names `HashedSuperAdminRole`
+// - adminRole is a registered name or a hex hash,which sets the role's admin role if not empty
+// - emit event `RoleRegistered` if succ
+func (accessControl *AccessControlContract) RegisterRole(ctx context.ContextInterface, msg context.Message, name string, description string, adminRole string) error {
+	caller, err := ctx.Caller()
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: caller")
+	}
+	if err = hasRole(ctx, HashedSuperAdminRole[:], caller); err != nil {
+		return errors.Wrap(err, "AccessControl: only default admin role")
+	}
+
+	role, err := registerRole(ctx, name, description)
+	if err != nil {
+		return errors.Wrap(err, "AccessControl: registerRole")
+	}
+
+	var admin []byte
+	if adminRole != "" {
+		if admin, err = resolveRole(ctx, adminRole); err != nil {
+			return errors.Wrap(err, "AccessControl: invalid adminRole")
+		}
+		if err = setRoleAdmin(ctx, role, admin); err != nil {
+			return errors.Wrap(err, "AccessControl: setRoleAdmin")
+		}
+	}
+
+	if err = ctx.EmitEvent("RoleRegistered", &EventRoleRegistered{
+		Role:        roleID(role),
+		Name:        name,
+		Description: description,
+		AdminRole:   roleID(admin),
+	}); err != nil {
+		return errors.Wrap(err, "AccessControl: event")
+	}
+
+	return nil
+}
+
+// registerRole stores the name and the description of the role named `name`
+func registerRole(ctx context.ContextInterface, name string, description string) ([]byte, error) {
+	// a name like a hash in hex could be mistaken for a hash when resolving roles
+	if name == "" || isHexRole(name) {
+		return nil, errors.Wrapf(ErrInvalidRoleName, "%q", name)
+	}
+
+	role := HashRole(name)
+	infoKey, err := ctx.GetStub().CreateCompositeKey(RoleInfoPrefix, []string{roleID(role)})
+	if err != nil {
+		return nil, err
+	}
+	nameKey, err := ctx.GetStub().CreateCompositeKey(RoleNamePrefix, []string{name})
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := ctx.State().Get(infoKey)
+	if err != nil {
+		return nil, err
+	}
+	if val != nil {
+		return nil, errors.Wrapf(ErrRoleAlreadyRegistered, "%q", name)
+	}
+
+	bytes, err := json.Marshal(&RegisteredRole{
+		Name:        name,
+		Description: description,
+		Role:        roleID(role),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = ctx.State().Put(infoKey, bytes); err != nil {
+		return nil, err
+	}
+	if err = ctx.State().Put(nameKey, role); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// RoleInfo returns the registered role by its name or hex hash
+func (accessControl *AccessControlContract) RoleInfo(ctx context.ContextInterface, role string) (*RegisteredRole, error) {
+	hashed, err := resolveRole(ctx, role)
+	if err != nil {
+		return nil, errors.Wrap(err, "AccessControl: invalid role")
+	}
+
+	info, err := getRoleInfo(ctx, hashed)
+	if err != nil {
+		return nil, errors.Wrap(err, "AccessControl: get role info")
+	}
+	if info == nil {
+		return nil, errors.Wrapf(ErrUnknownRole, "%s is not registered", role)
+	}
+
+	return info, nil
+}
+
+// ListRoles returns all the registered roles
+func (accessControl *AccessControlContract) ListRoles(ctx context.ContextInterface) ([]RegisteredRole, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(RoleInfoPrefix, []string{})
+	if err != nil {
+		return nil, errors.Wrap(err, "AccessControl: list roles")
+	}
+	defer iterator.Close()
+
+	roles := []RegisteredRole{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, errors.Wrap(err, "AccessControl: list roles")
+		}
+		info := new(RegisteredRole)
+		if err = json.Unmarshal(kv.GetValue(), info); err != nil {
+			return nil, errors.Wrap(err, "AccessControl: unmarshal role")
+		}
+		if err = fillRoleAdmin(ctx, info); err != nil {
+			return nil, errors.Wrap(err, "AccessControl: get role admin")
+		}
+		roles = append(roles, *info)
+	}
+
+	return roles, nil
+}
+
+// getRoleInfo returns the registered role with its current admin role,nil if the role is not registered
+func getRoleInfo(ctx context.ContextInterface, role []byte) (*RegisteredRole, error) {
+	infoKey, err := ctx.GetStub().CreateCompositeKey(RoleInfoPrefix, []string{roleID(role)})
+	if err != nil {
+		return nil, err
+	}
+	val, err := ctx.State().Get(infoKey)
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return nil, nil
+	}
+
+	info := new(RegisteredRole)
+	if err = json.Unmarshal(val, info); err != nil {
+		return nil, err
+	}
+	if err = fillRoleAdmin(ctx, info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// fillRoleAdmin sets the current admin role of a registered role and its name
+func fillRoleAdmin(ctx context.ContextInterface, info *RegisteredRole) error {
+	role, err := hex.DecodeString(info.Role)
+	if err != nil {
+		return err
+	}
+	admin, err := getRoleAdmin(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	info.AdminRole = roleID(admin)
+	info.AdminRoleName = ""
+	if len(admin) == 0 {
+		return nil
+	}
+
+	infoKey, err := ctx.GetStub().CreateCompositeKey(RoleInfoPrefix, []string{roleID(admin)})
+	if err != nil {
+		return err
+	}
+	val, err := ctx.State().Get(infoKey)
+	if err != nil {
+		return err
+	}
+	if val != nil {
+		adminInfo := new(RegisteredRole)
+		if err = json.Unmarshal(val, adminInfo); err != nil {
+			return err
+		}
+		info.AdminRoleName = adminInfo.Name
+	}
+
+	return nil
+}
+
+// resolveRole returns the role by a registered name,or by its 32-byte hash in hex with an optional 0x prefix
+func resolveRole(ctx context.ContextInterface, role string) ([]byte, error) {
+	if role == "" {
+		return nil, errors.Wrap(ErrUnknownRole, "empty role")
+	}
+
+	nameKey, err := ctx.GetStub().CreateCompositeKey(RoleNamePrefix, []string{role})
+	if err != nil {
+		return nil, err
+	}
+	val, err := ctx.State().Get(nameKey)
+	if err != nil {
+		return nil, err
+	}
+	if val != nil {
+		return val, nil
+	}
+
+	if !isHexRole(role) {
+		return nil, errors.Wrapf(ErrUnknownRole, "%q is neither a registered name nor a 32-byte hex hash", role)
+	}
+	return hex.DecodeString(strings.TrimPrefix(role, "0x"))
+}
+
+// isHexRole tells whether role is a 32-byte hash in hex(64 hex characters) with an optional `0x` prefix
+func isHexRole(role string) bool {
+	trimmed := strings.TrimPrefix(role, "0x")
+	if len(trimmed) != 2*roleLength {
+		return false
+	}
+	_, err := hex.DecodeString(trimmed)
+	return err == nil
+}
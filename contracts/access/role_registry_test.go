@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package access
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveRole(t *testing.T) {
+	alice, _, _ := newAccounts(t)
+	cc := newAccessControl(t, alice)
+
+	assert.NoError(t, cc.Run(alice, "", false, nil, func(ctx *context.Context, _ *context.Message) error {
+		minter, err := registerRole(ctx, "minter", "mints tokens")
+		assert.NoError(t, err)
+		hexMinter := library.BytesToHexString(minter)
+
+		for _, role := range []string{"minter", hexMinter, "0x" + hexMinter, "0x" + strings.ToUpper(hexMinter)} {
+			resolved, err := resolveRole(ctx, role)
+			assert.NoError(t, err, role)
+			assert.Equal(t, minter, resolved, role)
+		}
+
+		// only a registered name or a 32-byte hash in hex is a role
+		for _, role := range []string{"", "0x", "burner", "cafe", "0xcafe", hexMinter[:62], hexMinter + "00", "0x" + hexMinter[:63] + "g"} {
+			_, err := resolveRole(ctx, role)
+			assert.ErrorIs(t, err, ErrUnknownRole, role)
+		}
+
+		// a name like a hash is rejected,while a short hex name is not mistaken for a hash
+		_, err = registerRole(ctx, "0x"+strings.Repeat("ab", 32), "")
+		assert.ErrorIs(t, err, ErrInvalidRoleName)
+		_, err = registerRole(ctx, "cafe", "")
+		assert.NoError(t, err)
+		resolved, err := resolveRole(ctx, "cafe")
+		assert.NoError(t, err)
+		assert.Equal(t, HashRole("cafe"), resolved)
+		return nil
+	}))
+}
+
+func TestRegisterRole(t *testing.T) {
+	alice, bob, _ := newAccounts(t)
+	cc := newAccessControl(t, alice)
+	hexMinter := library.BytesToHexString(HashRole("minter"))
+	hexSuperAdmin := library.BytesToHexString(HashedSuperAdminRole[:])
+
+	assert.NoError(t, cc.Submit(alice, "RegisterRole", "minter", "mints tokens", SuperAdminRole))
+	events := cc.Events()
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, "RoleAdminChanged", events[0].Name)
+		assert.Equal(t, "RoleRegistered", events[1].Name)
+		assert.JSONEq(t, `{"Role":"`+hexMinter+`","Name":"minter","Description":"mints tokens","AdminRole":"`+hexSuperAdmin+`"}`, string(events[1].Payload))
+	}
+
+	expected := &RegisteredRole{
+		Name:          "minter",
+		Description:   "mints tokens",
+		Role:          hexMinter,
+		AdminRole:     hexSuperAdmin,
+		AdminRoleName: SuperAdminRole,
+	}
+	for _, role := range []string{"minter", hexMinter, "0x" + hexMinter} {
+		info, err := cc.Invoke(nil, "RoleInfo", role)
+		assert.NoError(t, err, role)
+		assert.Equal(t, expected, info, role)
+	}
+	roles, err := cc.Invoke(nil, "ListRoles")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []RegisteredRole{
+		{Name: SuperAdminRole, Description: "default admin role", Role: hexSuperAdmin},
+		*expected,
+	}, roles)
+
+	// a role is registered once and only by the default admin role
+	assert.ErrorIs(t, cc.Submit(alice, "RegisterRole", "minter", "", ""), ErrRoleAlreadyRegistered)
+	assert.Error(t, cc.Submit(bob, "RegisterRole", "burner", "", ""))
+	_, err = cc.Invoke(nil, "RoleInfo", "burner")
+	assert.ErrorIs(t, err, ErrUnknownRole)
+	_, err = cc.Invoke(nil, "RoleInfo", library.BytesToHexString(HashRole("burner")))
+	assert.ErrorIs(t, err, ErrUnknownRole)
+
+	// roles are granted and checked by names or hex hashes
+	assert.NoError(t, cc.Submit(alice, "GrantRole", "minter", bob.String()))
+	for _, role := range []string{"minter", hexMinter, "0x" + hexMinter} {
+		assertRole(t, cc, role, bob, true)
+	}
+	assert.NoError(t, cc.Submit(alice, "RevokeRole", "0x"+hexMinter, bob.String()))
+	assertRole(t, cc, "minter", bob, false)
+}
+
+func TestRoleAdmin(t *testing.T) {
+	alice, bob, _ := newAccounts(t)
+	cc := newAccessControl(t, alice)
+	assert.NoError(t, cc.Submit(alice, "RegisterRole", "minter", "mints tokens", ""))
+	assert.NoError(t, cc.Submit(alice, "RegisterRole", "operator", "operates minters", ""))
+	hexMinter := library.BytesToHexString(HashRole("minter"))
+	hexOperator := library.BytesToHexString(HashRole("operator"))
+
+	admin, err := cc.Invoke(nil, "GetRoleAdmin", "minter")
+	assert.NoError(t, err)
+	assert.Equal(t, "", admin)
+
+	// roles are set by names or hex hashes and returned in hex
+	assert.NoError(t, cc.Submit(alice, "SetRoleAdmin", "minter", "operator"))
+	assert.Equal(t, "RoleAdminChanged", cc.Events()[0].Name)
+	for _, role := range []string{"minter", hexMinter, "0x" + hexMinter} {
+		admin, err = cc.Invoke(nil, "GetRoleAdmin", role)
+		assert.NoError(t, err)
+		assert.Equal(t, hexOperator, admin)
+	}
+	assert.NoError(t, cc.Submit(alice, "SetRoleAdmin", hexMinter, SuperAdminRole))
+	admin, err = cc.Invoke(nil, "GetRoleAdmin", "minter")
+	assert.NoError(t, err)
+	assert.Equal(t, library.BytesToHexString(HashedSuperAdminRole[:]), admin)
+
+	assert.ErrorIs(t, cc.Submit(alice, "SetRoleAdmin", "minter", "burner"), ErrUnknownRole)
+	assert.ErrorIs(t, cc.Submit(alice, "SetRoleAdmin", "", "operator"), ErrUnknownRole)
+	assert.Error(t, cc.Submit(alice, "SetRoleAdmin", "minter", hexMinter))
+	_, err = cc.Invoke(nil, "GetRoleAdmin", "burner")
+	assert.ErrorIs(t, err, ErrUnknownRole)
+
+	// only the default admin role sets admin roles
+	assert.Error(t, cc.Submit(bob, "SetRoleAdmin", "minter", "operator"))
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delegation
+
+import (
+	"github.com/bestchains/bestchains-contracts/contracts/nonce"
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrDelegatedCall is returned when a session key manages delegations
+	ErrDelegatedCall = errors.New("delegations can not be managed by a session key")
+	// ErrNoFunctions is returned when a delegation allows no function
+	ErrNoFunctions = errors.New("no delegated functions")
+)
+
+var _ IDelegation = new(DelegationContract)
+
+// DelegationContract keeps the session keys of message senders in the world state.
+// Other contracts of the same chaincode which cap spending accept messages signed by session keys
+// with `context.WithDelegationStore(context.LedgerDelegationStore{})`
+type DelegationContract struct {
+	contractapi.Contract
+
+	nonce.INonce
+
+	hooks *context.Hooks
+
+	store context.DelegationStore
+}
+
+// NewDelegationContract creates a new DelegationContract instance with the given nonce contract.
+func NewDelegationContract(nonceContract nonce.INonce, opts ...context.HookOption) *DelegationContract {
+	delegationContract := new(DelegationContract)
+	delegationContract.Name = "org.bestchains.com.DelegationContract"
+	delegationContract.store = context.LedgerDelegationStore{}
+	delegationContract.INonce = nonceContract
+
+	delegationContract.TransactionContextHandler = new(context.Context)
+	delegationContract.hooks = context.NewHooks(opts...)
+	delegationContract.hooks.Register(delegationContract)
+	delegationContract.BeforeTransaction = delegationContract.hooks.BeforeTransaction
+	delegationContract.AfterTransaction = delegationContract.hooks.AfterTransaction
+
+	return delegationContract
+}
+
+// MessageVersion returns the message version clients should sign with
+func (dc *DelegationContract) MessageVersion(ctx context.ContextInterface) (uint8, error) {
+	return dc.hooks.MessageVersion(ctx)
+}
+
+// onlySender checks the message is signed by its sender rather than a session key
+func onlySender(ctx context.ContextInterface) error {
+	if ctx.MsgSigner() != ctx.MsgSender() {
+		return ErrDelegatedCall
+	}
+	return nil
+}
+
+// Delegate authorizes the session key `delegate` to call functions on behalf of the message sender
+// until expiresAt(unix timestamp in seconds),spending up to spendingCap(a decimal uint256) in total.
+// It replaces the previous delegation to the same session key.
+// This function triggers a Delegated event
+func (dc *DelegationContract) Delegate(ctx context.ContextInterface, msg context.Message, delegate string, functions []string, spendingCap string, expiresAt int64) error {
+	var err error
+	if err = onlySender(ctx); err != nil {
+		return err
+	}
+	capValue, err := math.ParseUint256(spendingCap)
+	if err != nil {
+		return errors.Wrap(err, "Delegation: invalid spending cap")
+	}
+
+	delegateAddr, err := library.ParseAddress(delegate)
+	if err != nil {
+		return err
+	}
+	if err = delegateAddr.Validate(); err != nil {
+		return err
+	}
+	if delegateAddr.Equal(ctx.MsgSender()) {
+		return errors.New("Delegation: can not delegate to self")
+	}
+	if len(functions) == 0 {
+		return ErrNoFunctions
+	}
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return err
+	}
+	if expiresAt <= txTimestamp.GetSeconds() {
+		return errors.Wrapf(context.ErrDelegationExpired, "expired at %d", expiresAt)
+	}
+
+	if err = dc.store.PutDelegation(ctx, &protocol.Delegation{
+		Delegator:   ctx.MsgSender().String(),
+		Delegate:    delegateAddr.String(),
+		Functions:   functions,
+		SpendingCap: capValue,
+		ExpiresAt:   expiresAt,
+	}); err != nil {
+		return errors.Wrap(err, "Delegation: failed to put delegation")
+	}
+
+	if err = ctx.EmitEvent("Delegated", &EventDelegated{
+		Delegator:   ctx.MsgSender(),
+		Delegate:    delegateAddr,
+		Functions:   functions,
+		SpendingCap: capValue,
+		ExpiresAt:   expiresAt,
+	}); err != nil {
+		return errors.Wrap(err, "Event Delegated")
+	}
+
+	return nil
+}
+
+// Revoke revokes the session key `delegate` of the message sender,which takes effect from the next transaction.
+// This function triggers a Revoked event
+func (dc *DelegationContract) Revoke(ctx context.ContextInterface, msg context.Message, delegate string) error {
+	var err error
+	if err = onlySender(ctx); err != nil {
+		return err
+	}
+
+	delegateAddr, err := library.ParseAddress(delegate)
+	if err != nil {
+		return err
+	}
+
+	delegation, err := dc.store.GetDelegation(ctx, ctx.MsgSender().String(), delegateAddr.String())
+	if err != nil {
+		return err
+	}
+	if delegation == nil {
+		return context.ErrDelegationNotFound
+	}
+	if err = dc.store.DeleteDelegation(ctx, ctx.MsgSender().String(), delegateAddr.String()); err != nil {
+		return errors.Wrap(err, "Delegation: failed to delete delegation")
+	}
+
+	if err = ctx.EmitEvent("Revoked", &EventRevoked{
+		Delegator: ctx.MsgSender(),
+		Delegate:  delegateAddr,
+	}); err != nil {
+		return errors.Wrap(err, "Event Revoked")
+	}
+
+	return nil
+}
+
+// GetDelegation returns the delegation from delegator to the session key `delegate`
+func (dc *DelegationContract) GetDelegation(ctx context.ContextInterface, delegator string, delegate string) (*protocol.Delegation, error) {
+	delegatorAddr, err := library.ParseAddress(delegator)
+	if err != nil {
+		return nil, err
+	}
+	delegateAddr, err := library.ParseAddress(delegate)
+	if err != nil {
+		return nil, err
+	}
+
+	delegation, err := dc.store.GetDelegation(ctx, delegatorAddr.String(), delegateAddr.String())
+	if err != nil {
+		return nil, err
+	}
+	if delegation == nil {
+		return nil, context.ErrDelegationNotFound
+	}
+	return delegation, nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delegation
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/contracts/nonce"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/context/contexttest"
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// spender is a contract which spends the spending caps of session keys
+type spender struct {
+	contractapi.Contract
+
+	hooks *context.Hooks
+}
+
+func newSpender() *spender {
+	s := new(spender)
+	s.Name = "spender"
+	s.TransactionContextHandler = new(context.Context)
+	s.hooks = context.NewHooks(context.WithDelegationStore(context.LedgerDelegationStore{}))
+	s.hooks.Register(s)
+	s.hooks.CapSpending()
+	s.BeforeTransaction = s.hooks.BeforeTransaction
+	s.AfterTransaction = s.hooks.AfterTransaction
+	return s
+}
+
+func (s *spender) Transfer(ctx context.ContextInterface, msg context.Message, to string, amount string) error {
+	value, err := math.ParseUint256(amount)
+	if err != nil {
+		return err
+	}
+	return s.hooks.Spend(ctx, "", value)
+}
+
+func (s *spender) Approve(ctx context.ContextInterface, msg context.Message, to string, amount string) error {
+	return nil
+}
+
+func TestDelegation(t *testing.T) {
+	dc := NewDelegationContract(nonce.NewNonceContract())
+	cc := contexttest.NewChaincode(t, "delegation", dc, newSpender())
+	cc.Now = 1000
+	delegator := contexttest.NewAccount(t)
+	sessionKey := delegator.SessionKey(t)
+	delegate := sessionKey.String()
+	functions := `["spender:Transfer"]`
+
+	// delegateKey delegates the session key to call functions until expiresAt
+	delegateKey := func(functions string, spendingCap string, expiresAt int64) {
+		assert.NoError(t, cc.Submit(delegator, "Delegate", delegate, functions, spendingCap, strconv.FormatInt(expiresAt, 10)))
+	}
+
+	t.Run("Delegate and GetDelegation", func(t *testing.T) {
+		delegateKey(functions, "10", 1100)
+		assert.Equal(t, "Delegated", cc.Events()[0].Name)
+
+		result, err := cc.Invoke(nil, "GetDelegation", delegator.String(), delegate)
+		assert.NoError(t, err)
+		delegation := result.(*protocol.Delegation)
+		assert.Equal(t, delegator.String(), delegation.Delegator)
+		assert.Equal(t, delegate, delegation.Delegate)
+		assert.Equal(t, []string{"spender:Transfer"}, delegation.Functions)
+		assert.Equal(t, math.NewUint256(10), delegation.SpendingCap)
+		assert.Equal(t, int64(1100), delegation.ExpiresAt)
+
+		_, err = cc.Invoke(nil, "GetDelegation", delegate, delegator.String())
+		assert.ErrorIs(t, err, context.ErrDelegationNotFound)
+	})
+
+	t.Run("Invalid delegations", func(t *testing.T) {
+		assert.ErrorIs(t, cc.Submit(delegator, "Delegate", delegate, `[]`, "10", "1100"), ErrNoFunctions)
+		assert.ErrorIs(t, cc.Submit(delegator, "Delegate", delegate, functions, "10", "1000"), context.ErrDelegationExpired)
+		assert.Error(t, cc.Submit(delegator, "Delegate", delegator.String(), functions, "10", "1100"))
+		assert.Error(t, cc.Submit(delegator, "Delegate", delegate, functions, "-1", "1100"))
+
+		// a session key can never manage delegations,as the delegation contract does not cap spending
+		assert.ErrorIs(t, cc.Submit(sessionKey, "Delegate", delegate, functions, "10", "1100"), context.ErrDelegationNotSupported)
+		assert.ErrorIs(t, cc.Submit(sessionKey, "Revoke", delegate), context.ErrDelegationNotSupported)
+	})
+
+	t.Run("Spending cap exhausted", func(t *testing.T) {
+		delegateKey(functions, "10", 1100)
+
+		assert.NoError(t, cc.Submit(sessionKey, "spender:Transfer", "to", "6"))
+		assert.ErrorIs(t, cc.Submit(sessionKey, "spender:Transfer", "to", "5"), context.ErrSpendingCapExceeded)
+		assert.NoError(t, cc.Submit(sessionKey, "spender:Transfer", "to", "4"))
+		assert.ErrorIs(t, cc.Submit(sessionKey, "spender:Transfer", "to", "1"), context.ErrSpendingCapExceeded)
+
+		// a new delegation starts with nothing spent
+		delegateKey(functions, "10", 1100)
+		assert.NoError(t, cc.Submit(sessionKey, "spender:Transfer", "to", "10"))
+	})
+
+	t.Run("Function not delegated", func(t *testing.T) {
+		delegateKey(functions, "10", 1100)
+		assert.ErrorIs(t, cc.Submit(sessionKey, "spender:Approve", "to", "1"), context.ErrFunctionNotDelegated)
+	})
+
+	t.Run("Expired delegation", func(t *testing.T) {
+		delegateKey(functions, "10", 1100)
+
+		cc.Now = 1101
+		defer func() { cc.Now = 1000 }()
+		assert.ErrorIs(t, cc.Submit(sessionKey, "spender:Transfer", "to", "1"), context.ErrDelegationExpired)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		delegateKey(functions, "10", 1100)
+
+		assert.NoError(t, cc.Submit(delegator, "Revoke", delegate))
+		_, err := cc.Invoke(nil, "GetDelegation", delegator.String(), delegate)
+		assert.ErrorIs(t, err, context.ErrDelegationNotFound)
+		assert.ErrorIs(t, cc.Submit(delegator, "Revoke", delegate), context.ErrDelegationNotFound)
+
+		// the session key is rejected from the next transaction
+		assert.ErrorIs(t, cc.Submit(sessionKey, "spender:Transfer", "to", "1"), context.ErrDelegationNotFound)
+	})
+}
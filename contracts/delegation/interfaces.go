@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delegation
+
+import (
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+)
+
+// EventDelegated emit when a delegator delegates a session key
+type EventDelegated struct {
+	Delegator   library.Address
+	Delegate    library.Address
+	Functions   []string
+	SpendingCap math.Uint256
+	ExpiresAt   int64
+}
+
+// EventRevoked emit when a delegator revokes a session key
+type EventRevoked struct {
+	Delegator library.Address
+	Delegate  library.Address
+}
+
+// IDelegation defines the interfaces which delegation contract must implement
+type IDelegation interface {
+	MessageVersion(ctx context.ContextInterface) (uint8, error)
+	Delegate(ctx context.ContextInterface, msg context.Message, delegate string, functions []string, spendingCap string, expiresAt int64) error
+	Revoke(ctx context.ContextInterface, msg context.Message, delegate string) error
+	GetDelegation(ctx context.ContextInterface, delegator string, delegate string) (*protocol.Delegation, error)
+}
@@ -51,10 +51,12 @@ type DepositoryContract struct {
 	nonce.INonce
 
 	access.IAccessControl
+
+	hooks *context.Hooks
 }
 
 // NewDepositoryContract creates a new DepositoryContract instance with the given nonce and access control contracts.
-func NewDepositoryContract(nonceContract nonce.INonce, aclContract access.IAccessControl) *DepositoryContract {
+func NewDepositoryContract(nonceContract nonce.INonce, aclContract access.IAccessControl, opts ...context.HookOption) *DepositoryContract {
 	depositoryContract := new(DepositoryContract)
 
 	// Set the name of the depository contract
@@ -64,17 +66,30 @@ func NewDepositoryContract(nonceContract nonce.INonce, aclContract access.IAcces
 	depositoryContract.INonce = nonceContract
 	depositoryContract.IAccessControl = aclContract
 
-	// Set the TransactionContextHandler and BeforeTransaction
+	// Set the TransactionContextHandler, BeforeTransaction and AfterTransaction
 	depositoryContract.TransactionContextHandler = new(context.Context)
-	depositoryContract.BeforeTransaction = context.BeforeTransaction
+	depositoryContract.hooks = context.NewHooks(opts...)
+	depositoryContract.hooks.Register(depositoryContract)
+	depositoryContract.BeforeTransaction = depositoryContract.hooks.BeforeTransaction
+	depositoryContract.AfterTransaction = depositoryContract.hooks.AfterTransaction
 
 	return depositoryContract
 }
 
+// MessageVersion returns the message version clients should sign with
+func (bc *DepositoryContract) MessageVersion(ctx context.ContextInterface) (uint8, error) {
+	return bc.hooks.MessageVersion(ctx)
+}
+
 // onlyRole checks if the caller has the specified role.
 func (bc *DepositoryContract) onlyRole(ctx context.ContextInterface, role []byte) error {
+	// Identify the caller by the identity policy.
+	caller, err := ctx.Caller()
+	if err != nil {
+		return errors.Wrap(err, "onlyRole")
+	}
 	// Check if the caller has the specified role.
-	result, err := bc.HasRole(ctx, role, ctx.MsgSender().String())
+	result, err := bc.HasRole(ctx, library.BytesToHexString(role), caller.String())
 	if err != nil {
 		return errors.Wrap(err, "onlyRole")
 	}
@@ -87,9 +102,9 @@ func (bc *DepositoryContract) onlyRole(ctx context.ContextInterface, role []byte
 }
 
 // Initialize initializes the DepositoryContract and returns an error if there is one.
-func (bc *DepositoryContract) Initialize(ctx context.ContextInterface) error {
+func (bc *DepositoryContract) Initialize(ctx context.ContextInterface, msg context.Message) error {
 	// Call the parent's Initialize function.
-	err := bc.IAccessControl.Initialize(ctx)
+	err := bc.IAccessControl.Initialize(ctx, msg)
 	if err != nil {
 		// If there was an error, return it.
 		return err
@@ -101,31 +116,22 @@ func (bc *DepositoryContract) Initialize(ctx context.ContextInterface) error {
 
 // EnableACL enables the access control list
 func (bc *DepositoryContract) EnableACL(ctx context.ContextInterface) error {
-	err := ctx.GetStub().PutState(EnableACLKey, library.True.Bytes())
-	if err != nil {
-		return err
-	}
-	return nil
+	return ctx.State().PutBool(library.True, EnableACLKey)
 }
 
 // DisableACL disables the access control list
 func (bc *DepositoryContract) DisableACL(ctx context.ContextInterface) error {
-	err := ctx.GetStub().PutState(EnableACLKey, library.False.Bytes())
-	if err != nil {
-		return err
-	}
-	return nil
+	return ctx.State().PutBool(library.False, EnableACLKey)
 }
 
 // aclEnabled returns a boolean indicating whether the access control list is enabled
 func (bc *DepositoryContract) aclEnabled(ctx context.ContextInterface) (bool, error) {
-	val, err := ctx.GetStub().GetState(EnableACLKey)
+	val, err := ctx.State().GetBool(EnableACLKey)
 	if err != nil {
 		return false, err
 	}
 
-	return library.BytesToBool(val).Bool(), nil
-
+	return val.Bool(), nil
 }
 
 // Total returns the total count
@@ -245,15 +251,6 @@ func (bc *DepositoryContract) BatchPutValue(ctx context.ContextInterface, msg co
 		}
 	}
 
-	// Check the nonce of the caller to prevent replay attacks.
-	if err = bc.INonce.Check(ctx, ctx.MsgSender().String(), msg.Nonce); err != nil {
-		return "", err
-	}
-	// Increment the nonce for the caller.
-	if _, err = bc.INonce.Increment(ctx, ctx.MsgSender().String()); err != nil {
-		return "", err
-	}
-
 	// Make sure batchVals is not empty.
 	if batchVals == "" {
 		return "", errors.New("empty batch value string")
@@ -327,14 +324,6 @@ func (bc *DepositoryContract) PutValue(ctx context.ContextInterface, msg context
 		}
 	}
 
-	// Increase nonce
-	if err = bc.INonce.Check(ctx, ctx.MsgSender().String(), msg.Nonce); err != nil {
-		return "", err
-	}
-	if _, err = bc.INonce.Increment(ctx, ctx.MsgSender().String()); err != nil {
-		return "", err
-	}
-
 	// Get current counter
 	curr, err := currentCounter(ctx)
 	if err != nil {
@@ -380,7 +369,7 @@ func putValue(ctx context.ContextInterface, curr *library.Counter, val string) (
 	if err != nil {
 		return 0, "", errors.Wrap(err, "Depository: invalid composite DepositoryKey")
 	}
-	err = ctx.GetStub().PutState(depositoryKey, []byte(kid))
+	err = ctx.State().Put(depositoryKey, []byte(kid))
 	if err != nil {
 		return 0, "", errors.Wrap(err, "Depository: failed to put DepositoryKey")
 	}
@@ -390,7 +379,7 @@ func putValue(ctx context.ContextInterface, curr *library.Counter, val string) (
 	if err != nil {
 		return 0, "", errors.Wrap(err, "Depository: invalid composite DepositoryValKey")
 	}
-	err = ctx.GetStub().PutState(depositoryValKey, []byte(val))
+	err = ctx.State().Put(depositoryValKey, []byte(val))
 	if err != nil {
 		return 0, "", errors.Wrap(err, "Depository: failed to put DepositoryKey")
 	}
@@ -404,33 +393,17 @@ func calculateKID(counter *library.Counter, val []byte) string {
 }
 
 func currentCounter(ctx context.ContextInterface) (*library.Counter, error) {
-	val, err := ctx.GetStub().GetState(IndexerKey)
+	val, err := library.NewPersistentCounter(ctx.State().CounterStore(), IndexerKey).Current()
 	if err != nil {
 		return nil, errors.Wrap(err, "Depository: failed to read counter")
 	}
-	return library.BytesToCounter(val)
+	return library.NewCounter(val), nil
 }
 
 func incrementCounter(ctx context.ContextInterface, offset uint64) error {
-	val, err := ctx.GetStub().GetState(IndexerKey)
-	if err != nil {
-		return errors.Wrap(err, "Depository: failed to read counter")
+	if err := library.NewPersistentCounter(ctx.State().CounterStore(), IndexerKey).Increment(offset); err != nil {
+		return errors.Wrap(err, "Depository: failed to increase counter")
 	}
-
-	counter, err := library.BytesToCounter(val)
-	if err != nil {
-		return errors.Wrap(err, "Depository: invalid counter")
-	}
-	err = counter.Increment(offset)
-	if err != nil {
-		return errors.Wrap(err, "Depositoy: increment counter")
-	}
-
-	err = ctx.GetStub().PutState(IndexerKey, counter.Bytes())
-	if err != nil {
-		return errors.Wrap(err, "Depository: failed to update counter")
-	}
-
 	return nil
 }
 
@@ -463,7 +436,7 @@ func getValByCounter(ctx context.ContextInterface, counter *library.Counter) ([]
 		return nil, errors.Wrap(err, "Depository: invalid composite DepositoryKey")
 	}
 
-	kid, err := ctx.GetStub().GetState(depositoryKey)
+	kid, err := ctx.State().Get(depositoryKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "Depository: failed to get kid with index")
 	}
@@ -492,7 +465,7 @@ func getValByKID(ctx context.ContextInterface, kid string) ([]byte, error) {
 		return nil, errors.Wrap(err, "Depository: invalid composite DepositoryValKey")
 	}
 
-	val, err := ctx.GetStub().GetState(depositoryValKey)
+	val, err := ctx.State().Get(depositoryValKey)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +39,7 @@ type IDepository interface {
 	nonce.INonce
 	access.IAccessControl
 	// Initialize the contract
-	Initialize(ctx context.ContextInterface) error
+	Initialize(ctx context.ContextInterface, msg context.Message) error
 	// EnableACL enable acl in Depository
 	EnableACL(ctx context.ContextInterface) error
 	// DisableACL disable acl in Depository
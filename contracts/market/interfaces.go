@@ -142,6 +142,9 @@ type IMarket interface {
 	// Initialize initializes the market service
 	Initialize(ctx context.ContextInterface) error
 
+	// MessageVersion returns the message version clients should sign with
+	MessageVersion(ctx context.ContextInterface) (uint8, error)
+
 	// Repository
 
 	// CreateRepo creates a new repository
@@ -40,12 +40,14 @@ var (
 type MarketContract struct {
 	contractapi.Contract
 	nonce.INonce
+
+	hooks *context.Hooks
 }
 
 var _ IMarket = new(MarketContract)
 
 // NewMarketContract creates a new instance of MarketContract with the specified nonce.
-func NewMarketContract(nonceContract nonce.INonce) *MarketContract {
+func NewMarketContract(nonceContract nonce.INonce, opts ...context.HookOption) *MarketContract {
 	// Create a new MarketContract instance.
 	marketContract := new(MarketContract)
 
@@ -58,8 +60,11 @@ func NewMarketContract(nonceContract nonce.INonce) *MarketContract {
 	// Set the transaction context handler of the MarketContract instance.
 	marketContract.TransactionContextHandler = new(context.Context)
 
-	// Set the before transaction handler of the MarketContract instance.
-	marketContract.BeforeTransaction = context.BeforeTransaction
+	// Set the before and after transaction handlers of the MarketContract instance.
+	marketContract.hooks = context.NewHooks(opts...)
+	marketContract.hooks.Register(marketContract)
+	marketContract.BeforeTransaction = marketContract.hooks.BeforeTransaction
+	marketContract.AfterTransaction = marketContract.hooks.AfterTransaction
 
 	// Return the newly created MarketContract instance.
 	return marketContract
@@ -69,6 +74,11 @@ func (lc *MarketContract) Initialize(ctx context.ContextInterface) error {
 	return errors.New("Initialize: not implemented")
 }
 
+// MessageVersion returns the message version clients should sign with
+func (lc *MarketContract) MessageVersion(ctx context.ContextInterface) (uint8, error) {
+	return lc.hooks.MessageVersion(ctx)
+}
+
 // CreateRepo creates a new Repository object and stores it on the ledger.
 //
 // ctx: is the context interface for calling chaincode functions, msg is the
@@ -76,15 +86,14 @@ func (lc *MarketContract) Initialize(ctx context.ContextInterface) error {
 // The function returns the ID of the new repository and an error, if any.
 func (lc *MarketContract) CreateRepo(ctx context.ContextInterface, msg context.Message, url string,
 ) (string, error) {
-	// Increment nonce
-	curr, err := lc.INonce.Increment(ctx, ctx.MsgSender().String())
+	// Identify the caller who owns the repository
+	caller, err := ctx.Caller()
 	if err != nil {
-		return "", errors.Wrap(err, "MarketContract: failed to increment nonce")
+		return "", errors.Wrap(err, "MarketContract: failed to identify caller")
 	}
 
-	// Calculate repository ID
-	// nonce here is greater than 0
-	id, err := calculateRepoID(ctx, ctx.MsgSender(), curr-1, url)
+	// Calculate repository ID with the caller and the nonce of the message
+	id, err := calculateRepoID(ctx, caller, msg.Nonce, url)
 	if err != nil {
 		return "", errors.Wrap(err, "MarketContract: failed to calculate repo id")
 	}
@@ -92,7 +101,7 @@ func (lc *MarketContract) CreateRepo(ctx context.ContextInterface, msg context.M
 	// Create new Repository object
 	repo := &Repository{
 		ID:    id,
-		Owner: ctx.MsgSender().String(),
+		Owner: caller.String(),
 		URL:   url,
 	}
 	val, _ := json.Marshal(repo)
@@ -102,7 +111,15 @@ func (lc *MarketContract) CreateRepo(ctx context.ContextInterface, msg context.M
 	if err != nil {
 		return "", errors.Wrap(err, "MarketContract: invalid composite RepoKey")
 	}
-	err = ctx.GetStub().PutState(repoKey, []byte(val))
+	// The nonce belongs to the message sender,so an operator caller may derive the same id twice
+	existing, err := ctx.State().Get(repoKey)
+	if err != nil {
+		return "", errors.Wrap(err, "MarketContract: failed to get Repository")
+	}
+	if existing != nil {
+		return "", errors.Errorf("MarketContract: repository %s already exists", id)
+	}
+	err = ctx.State().Put(repoKey, []byte(val))
 	if err != nil {
 		return "", errors.Wrap(err, "MarketContract: failed to put Repository")
 	}
@@ -190,10 +207,10 @@ func (lc *MarketContract) PublishComponent(ctx context.ContextInterface, msg con
 		return "", errors.New("PublishComponent: invalid input")
 	}
 
-	// Increment the nonce.
-	_, err = lc.INonce.Increment(ctx, ctx.MsgSender().String())
+	// Identify the caller who owns the component
+	caller, err := ctx.Caller()
 	if err != nil {
-		return "", errors.Wrap(err, "MarketContract: failed to increment nonce")
+		return "", errors.Wrap(err, "MarketContract: failed to identify caller")
 	}
 
 	// Create composite key.
@@ -203,7 +220,7 @@ func (lc *MarketContract) PublishComponent(ctx context.ContextInterface, msg con
 	}
 
 	// Get the component.
-	swBytes, err := ctx.GetStub().GetState(swKey)
+	swBytes, err := ctx.State().Get(swKey)
 	if err != nil {
 		return "", errors.Wrap(err, "MarketContract: failed to get Component")
 	}
@@ -216,8 +233,8 @@ func (lc *MarketContract) PublishComponent(ctx context.ContextInterface, msg con
 			return "", errors.Wrap(err, "MarketContract: failed to unmarshal Component")
 		}
 
-		// Check if the sender is the owner of the component.
-		if sw.Owner == ctx.MsgSender().String() {
+		// Check if the caller is the owner of the component.
+		if !library.Address(sw.Owner).Equal(caller) {
 			return "", errors.New("PublishComponent: only component owner can publish a new version")
 		}
 
@@ -230,14 +247,14 @@ func (lc *MarketContract) PublishComponent(ctx context.ContextInterface, msg con
 	} else {
 		// Create a new component.
 		sw.UUID = swUUID
-		sw.Owner = ctx.MsgSender().String()
+		sw.Owner = caller.String()
 		sw.RepoID = repoID
-		sw.Versions = append(sw.Versions, Version{Number: version})
 	}
+	sw.Versions = append(sw.Versions, Version{Number: version})
 
 	// Marshal the component and put it in the state.
 	val, _ := json.Marshal(sw)
-	err = ctx.GetStub().PutState(swKey, []byte(val))
+	err = ctx.State().Put(swKey, []byte(val))
 	if err != nil {
 		return "", errors.Wrap(err, "MarketContract: failed to put Component")
 	}
@@ -262,15 +279,9 @@ func (lc *MarketContract) PublishComponent(ctx context.ContextInterface, msg con
 func (lc *MarketContract) EndorseComponent(ctx context.ContextInterface, msg context.Message, repoID string, swUUID string, version string) error {
 	var err error
 
-	// Increment nonce
-	_, err = lc.INonce.Increment(ctx, ctx.MsgSender().String())
-	if err != nil {
-		return errors.Wrap(err, "MarketContract: failed to increment nonce")
-	}
-
 	// Check if repository exists
 	repoKey, _ := ctx.GetStub().CreateCompositeKey(RepoKeyPrefix, []string{repoID})
-	repoBytes, _ := ctx.GetStub().GetState(repoKey)
+	repoBytes, _ := ctx.State().Get(repoKey)
 	if repoBytes == nil {
 		return errors.New("EndorseComponent: repo not found")
 	}
@@ -281,13 +292,17 @@ func (lc *MarketContract) EndorseComponent(ctx context.ContextInterface, msg con
 	}
 
 	// Check if caller is the owner of the repository
-	if repo.Owner != ctx.MsgSender().String() {
+	caller, err := ctx.Caller()
+	if err != nil {
+		return errors.Wrap(err, "MarketContract: failed to identify caller")
+	}
+	if !library.Address(repo.Owner).Equal(caller) {
 		return errors.New("EndorseComponent: only repo owner can endorse a component")
 	}
 
 	// Check if component exists
 	swKey, _ := ctx.GetStub().CreateCompositeKey(ComponentKeyPrefix, []string{repoID, swUUID})
-	swBytes, _ := ctx.GetStub().GetState(swKey)
+	swBytes, _ := ctx.State().Get(swKey)
 	if swBytes == nil {
 		return errors.New("EndorseComponent: component not found")
 	}
@@ -305,7 +320,7 @@ func (lc *MarketContract) EndorseComponent(ctx context.ContextInterface, msg con
 			}
 			versioned.SignedByRepoOwner = true
 			val, _ := json.Marshal(sw)
-			err = ctx.GetStub().PutState(swKey, []byte(val))
+			err = ctx.State().Put(swKey, []byte(val))
 			if err != nil {
 				return errors.Wrap(err, "MarketContract: failed to put Component")
 			}
@@ -351,14 +366,14 @@ func (lc *MarketContract) GetComponents(ctx context.ContextInterface, repoID str
 // ApplyLicense applies a license to a component for a specific repository.
 // It returns the license ID if successful, and an error otherwise.
 func (lc *MarketContract) ApplyLicense(ctx context.ContextInterface, msg context.Message, repoID string, componentID string) (string, error) {
-	// increment sender's nonce
-	curr, err := lc.INonce.Increment(ctx, ctx.MsgSender().String())
+	// identify the caller who applies the license
+	caller, err := ctx.Caller()
 	if err != nil {
-		return "", errors.Wrap(err, "MarketContract: failed to increment nonce")
+		return "", errors.Wrap(err, "MarketContract: failed to identify caller")
 	}
 
 	// calculate license id
-	licenseID, err := calculateLicenseID(ctx, ctx.MsgSender(), curr-1, repoID, componentID)
+	licenseID, err := calculateLicenseID(ctx, caller, msg.Nonce, repoID, componentID)
 	if err != nil {
 		return "", errors.Wrap(err, "MarketContract: failed to calculate license id")
 	}
@@ -368,20 +383,27 @@ func (lc *MarketContract) ApplyLicense(ctx context.ContextInterface, msg context
 	if err != nil {
 		return "", errors.Wrap(err, "MarketContract: failed to create license key")
 	}
+	existing, err := ctx.State().Get(licenseKey)
+	if err != nil {
+		return "", errors.Wrap(err, "MarketContract: failed to get License")
+	}
+	if existing != nil {
+		return "", errors.Errorf("MarketContract: license %s already exists", licenseID)
+	}
 
 	// init license
 	license := &License{
 		ID:          licenseID,
 		RepoID:      repoID,
 		ComponentID: componentID,
-		IssueBy:     ctx.MsgSender().String(),
-		IssueTo:     ctx.MsgSender().String(),
+		IssueBy:     caller.String(),
+		IssueTo:     caller.String(),
 		Status:      Applying,
 	}
 
 	// store license with status Applying
 	val, _ := json.Marshal(license)
-	err = ctx.GetStub().PutState(licenseKey, []byte(val))
+	err = ctx.State().Put(licenseKey, []byte(val))
 	if err != nil {
 		return "", errors.Wrap(err, "MarketContract: failed to put License")
 	}
@@ -423,18 +445,12 @@ func (lc *MarketContract) GetLicenses(ctx context.ContextInterface) ([]License,
 
 // IssueLicense issues a license for a component
 func (lc *MarketContract) IssueLicense(ctx context.ContextInterface, msg context.Message, licenseID string, encActivationCode string) error {
-	// increment sender's nonce
-	_, err := lc.INonce.Increment(ctx, ctx.MsgSender().String())
-	if err != nil {
-		return errors.Wrap(err, "MarketContract: failed to increment nonce")
-	}
-
 	// get license from licenseID
 	licenseKey, err := ctx.GetStub().CreateCompositeKey(LicenseKeyPrefix, []string{licenseID})
 	if err != nil {
 		return errors.Wrap(err, "MarketContract: failed to create license key")
 	}
-	licenseBytes, err := ctx.GetStub().GetState(licenseKey)
+	licenseBytes, err := ctx.State().Get(licenseKey)
 	if err != nil {
 		return errors.Wrap(err, "MarketContract: failed to get license")
 	}
@@ -449,7 +465,7 @@ func (lc *MarketContract) IssueLicense(ctx context.ContextInterface, msg context
 
 	// get the relevent component
 	componentKey, _ := ctx.GetStub().CreateCompositeKey(ComponentKeyPrefix, []string{lic.RepoID, lic.ComponentID})
-	componentBytes, err := ctx.GetStub().GetState(componentKey)
+	componentBytes, err := ctx.State().Get(componentKey)
 	if err != nil {
 		return errors.Wrap(err, "MarketContract: failed to get component")
 	}
@@ -462,18 +478,22 @@ func (lc *MarketContract) IssueLicense(ctx context.ContextInterface, msg context
 		return errors.Wrap(err, "MarketContract: failed to unmarshal Component")
 	}
 
-	// check whether message sender is the component owner
-	if ctx.MsgSender().String() != sw.Owner {
-		return errors.New("MarketContract: message sender is not the component owner")
+	// check whether the caller is the component owner
+	caller, err := ctx.Caller()
+	if err != nil {
+		return errors.Wrap(err, "MarketContract: failed to identify caller")
+	}
+	if !caller.Equal(library.Address(sw.Owner)) {
+		return errors.New("MarketContract: caller is not the component owner")
 	}
 
 	// set license status to `Issued`,then store it to database
 	preStatus := lic.Status
 	lic.Status = Issued
-	lic.IssueBy = ctx.MsgSender().String()
+	lic.IssueBy = caller.String()
 
 	val, _ := json.Marshal(lic)
-	err = ctx.GetStub().PutState(licenseKey, []byte(val))
+	err = ctx.State().Put(licenseKey, []byte(val))
 	if err != nil {
 		return errors.Wrap(err, "MarketContract: failed to put License")
 	}
@@ -488,18 +508,12 @@ func (lc *MarketContract) IssueLicense(ctx context.ContextInterface, msg context
 	return nil
 }
 func (lc *MarketContract) RejectLicense(ctx context.ContextInterface, msg context.Message, licenseID string) error {
-	// increment sender's nonce
-	_, err := lc.INonce.Increment(ctx, ctx.MsgSender().String())
-	if err != nil {
-		return errors.Wrap(err, "MarketContract: failed to increment nonce")
-	}
-
 	// get license from licenseID
 	licenseKey, err := ctx.GetStub().CreateCompositeKey(LicenseKeyPrefix, []string{licenseID})
 	if err != nil {
 		return errors.Wrap(err, "MarketContract: failed to create license key")
 	}
-	licenseBytes, err := ctx.GetStub().GetState(licenseKey)
+	licenseBytes, err := ctx.State().Get(licenseKey)
 	if err != nil {
 		return errors.Wrap(err, "MarketContract: failed to get license")
 	}
@@ -514,7 +528,7 @@ func (lc *MarketContract) RejectLicense(ctx context.ContextInterface, msg contex
 
 	// get the relevent component
 	componentKey, _ := ctx.GetStub().CreateCompositeKey(ComponentKeyPrefix, []string{lic.RepoID, lic.ComponentID})
-	componentBytes, err := ctx.GetStub().GetState(componentKey)
+	componentBytes, err := ctx.State().Get(componentKey)
 	if err != nil {
 		return errors.Wrap(err, "MarketContract: failed to get component")
 	}
@@ -526,18 +540,22 @@ func (lc *MarketContract) RejectLicense(ctx context.ContextInterface, msg contex
 	if err != nil {
 		return errors.Wrap(err, "MarketContract: failed to unmarshal Component")
 	}
-	// check whether message sender is the component owner
-	if ctx.MsgSender().String() != sw.Owner {
-		return errors.New("MarketContract: message sender is not the component owner")
+	// check whether the caller is the component owner
+	caller, err := ctx.Caller()
+	if err != nil {
+		return errors.Wrap(err, "MarketContract: failed to identify caller")
+	}
+	if !caller.Equal(library.Address(sw.Owner)) {
+		return errors.New("MarketContract: caller is not the component owner")
 	}
 
 	// set license status to `Issued`,then store it to database
 	preStatus := lic.Status
 	lic.Status = Rejected
-	lic.IssueBy = ctx.MsgSender().String()
+	lic.IssueBy = caller.String()
 
 	val, _ := json.Marshal(lic)
-	err = ctx.GetStub().PutState(licenseKey, []byte(val))
+	err = ctx.State().Put(licenseKey, []byte(val))
 	if err != nil {
 		return errors.Wrap(err, "MarketContract: failed to put License")
 	}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package market
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/contracts/nonce"
+	"github.com/bestchains/bestchains-contracts/library/context/contexttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishComponent(t *testing.T) {
+	market := NewMarketContract(nonce.NewNonceContract())
+	cc := contexttest.NewChaincode(t, "market", market)
+	alice, bob := contexttest.NewAccount(t), contexttest.NewAccount(t)
+
+	assert.NoError(t, cc.Submit(alice, "PublishComponent", "repo", "component", "v1"))
+
+	// only the owner publishes new versions
+	assert.Error(t, cc.Submit(bob, "PublishComponent", "repo", "component", "v2"))
+	assert.NoError(t, cc.Submit(alice, "PublishComponent", "repo", "component", "v2"))
+	assert.Error(t, cc.Submit(alice, "PublishComponent", "repo", "component", "v1"))
+
+	components, err := cc.Invoke(bob, "GetComponents", "repo")
+	assert.NoError(t, err)
+	assert.Len(t, components, 1)
+	assert.Equal(t, alice.String(), components.([]Component)[0].Owner)
+	assert.Equal(t, []Version{{Number: "v1"}, {Number: "v2"}}, components.([]Component)[0].Versions)
+}
+
+func TestLegacyOwners(t *testing.T) {
+	market := NewMarketContract(nonce.NewNonceContract())
+	cc := contexttest.NewChaincode(t, "market", market)
+	alice, bob := contexttest.NewAccount(t), contexttest.NewAccount(t)
+
+	// owners stored in mixed case before addresses were canonical
+	legacy := map[string]interface{}{
+		RepoKeyPrefix:      &Repository{ID: "repo", Owner: alice.Address.Checksum()},
+		ComponentKeyPrefix: &Component{UUID: "component", RepoID: "repo", Owner: alice.Address.Checksum(), Versions: []Version{{Number: "v1"}}},
+	}
+	cc.Stub.MockTransactionStart("legacy")
+	for objectType, value := range legacy {
+		attributes := []string{"repo"}
+		if objectType == ComponentKeyPrefix {
+			attributes = append(attributes, "component")
+		}
+		key, err := cc.Stub.CreateCompositeKey(objectType, attributes)
+		assert.NoError(t, err)
+		bytes, err := json.Marshal(value)
+		assert.NoError(t, err)
+		assert.NoError(t, cc.Stub.PutState(key, bytes))
+	}
+	cc.Stub.MockTransactionEnd("legacy")
+
+	// owners are compared regardless of their cases,so they need no migration
+	assert.Error(t, cc.Submit(bob, "PublishComponent", "repo", "component", "v2"))
+	assert.NoError(t, cc.Submit(alice, "PublishComponent", "repo", "component", "v2"))
+	assert.Error(t, cc.Submit(bob, "EndorseComponent", "repo", "component", "v2"))
+	assert.NoError(t, cc.Submit(alice, "EndorseComponent", "repo", "component", "v2"))
+}
@@ -21,11 +21,14 @@ import (
 )
 
 const (
-	NoncePrefix = "nonce~account"
+	NoncePrefix = context.NoncePrefix
 )
 
+// INonce exposes the nonces of message senders kept by context.LedgerNonceStore.
+// It is read only,as nonces are only incremented by the hooks when they use signed messages.
 type INonce interface {
 	Check(context.ContextInterface, string, uint64) error
 	Current(context.ContextInterface, string) (uint64, error)
+	// Deprecated: Increment always returns ErrReadOnlyNonce,as nonces are only incremented by signed calls
 	Increment(context.ContextInterface, string) (uint64, error)
 }
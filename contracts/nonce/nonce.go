@@ -17,28 +17,33 @@ limitations under the License.
 package nonce
 
 import (
-	"errors"
-
-	"github.com/bestchains/bestchains-contracts/library"
 	"github.com/bestchains/bestchains-contracts/library/context"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/pkg/errors"
 )
 
 var (
-	ErrInvalidMessageNonce = errors.New("nonce mistmatch")
+	ErrInvalidMessageNonce = context.ErrInvalidNonce
+	// ErrReadOnlyNonce is returned by the deprecated Increment
+	ErrReadOnlyNonce = errors.New("nonces are only incremented by signed calls")
 )
 
 var _ INonce = new(Nonce)
 
 type Nonce struct {
 	contractapi.Contract
+
+	hooks *context.Hooks
 }
 
-func NewNonceContract() INonce {
+func NewNonceContract(opts ...context.HookOption) INonce {
 	nonceContract := new(Nonce)
 	nonceContract.Name = "org.bestchains.com.NonceContract"
 	nonceContract.TransactionContextHandler = new(context.Context)
-	nonceContract.BeforeTransaction = context.BeforeTransaction
+	nonceContract.hooks = context.NewHooks(opts...)
+	nonceContract.hooks.Register(nonceContract)
+	nonceContract.BeforeTransaction = nonceContract.hooks.BeforeTransaction
+	nonceContract.AfterTransaction = nonceContract.hooks.AfterTransaction
 	return nonceContract
 }
 
@@ -53,44 +58,15 @@ func (nonce *Nonce) Check(ctx context.ContextInterface, account string, dstNonce
 	return nil
 }
 
+// Current returns the current nonce of the account regardless of the case of its address
 func (nonce *Nonce) Current(ctx context.ContextInterface, account string) (uint64, error) {
-	nonceKey, err := ctx.GetStub().CreateCompositeKey(NoncePrefix, []string{account})
-	if err != nil {
-		return 0, err
-	}
-	val, err := ctx.GetStub().GetState(nonceKey)
-	if err != nil {
-		return 0, err
-	}
-
-	counter, err := library.BytesToCounter(val)
-	if err != nil {
-		return 0, err
-	}
-
-	return counter.Current(), nil
+	return context.LedgerNonceStore{}.Current(ctx, account)
 }
 
+// Increment always returns ErrReadOnlyNonce.
+//
+// Deprecated: nonces are only incremented by the hooks when they use signed messages,
+// so anybody calling it could invalidate the messages others have signed.
 func (nonce *Nonce) Increment(ctx context.ContextInterface, account string) (uint64, error) {
-	nonceKey, err := ctx.GetStub().CreateCompositeKey(NoncePrefix, []string{account})
-	if err != nil {
-		return 0, err
-	}
-	val, err := ctx.GetStub().GetState(nonceKey)
-	if err != nil {
-		return 0, err
-	}
-	counter, err := library.BytesToCounter(val)
-	if err != nil {
-		return 0, err
-	}
-	err = counter.Increment(1)
-	if err != nil {
-		return 0, err
-	}
-	err = ctx.GetStub().PutState(nonceKey, counter.Bytes())
-	if err != nil {
-		return 0, err
-	}
-	return counter.Current(), nil
+	return 0, ErrReadOnlyNonce
 }
@@ -49,6 +49,20 @@ var _ ITimeLock = new(TimeLock)
 // TimeLock provides simple key-value Get/Put with time lock function as a usage example
 type TimeLock struct {
 	contractapi.Contract
+
+	hooks *context.Hooks
+}
+
+// NewTimeLockContract creates a TimeLock named name
+func NewTimeLockContract(name string, opts ...context.HookOption) *TimeLock {
+	tlc := new(TimeLock)
+	tlc.Name = name
+	tlc.TransactionContextHandler = new(context.Context)
+	tlc.hooks = context.NewHooks(opts...)
+	tlc.hooks.Register(tlc)
+	tlc.BeforeTransaction = tlc.hooks.BeforeTransaction
+	tlc.AfterTransaction = tlc.hooks.AfterTransaction
+	return tlc
 }
 
 // Schedule sets a time lock for a {key}-{value} pair, which will be released for any get/set/del after {duration}.
@@ -93,7 +107,7 @@ func (tlc *TimeLock) Schedule(ctx context.ContextInterface, key string, value st
 	hashString := hex.EncodeToString(hash[12:])
 
 	// Save json data
-	err = ctx.GetStub().PutState(hashString, event)
+	err = ctx.State().Put(hashString, event)
 	if err != nil {
 		return "", err
 	}
@@ -105,7 +119,7 @@ func (tlc *TimeLock) Schedule(ctx context.ContextInterface, key string, value st
 func (tlc *TimeLock) Execute(ctx context.ContextInterface, opHash string) error {
 
 	// Get json data from ledger
-	iByte, err := ctx.GetStub().GetState(opHash)
+	iByte, err := ctx.State().Get(opHash)
 	if err != nil {
 		return fmt.Errorf("get state failed: %s", err)
 	}
@@ -130,7 +144,7 @@ func (tlc *TimeLock) Execute(ctx context.ContextInterface, opHash string) error
 	// Save the entry
 	if operation.Timestamp.IsExpired() {
 		if operation.OpType == "put" {
-			err = ctx.GetStub().PutState(entry.Key, []byte(entry.Value))
+			err = ctx.State().Put(entry.Key, []byte(entry.Value))
 			if err != nil {
 				return err
 			}
@@ -143,7 +157,7 @@ func (tlc *TimeLock) Execute(ctx context.ContextInterface, opHash string) error
 
 // GetValue returns the value of the given {key}.
 func (tlc *TimeLock) GetValue(ctx context.ContextInterface, key string) (string, error) {
-	bytes, err := ctx.GetStub().GetState(key)
+	bytes, err := ctx.State().Get(key)
 	if err != nil {
 		return "", err
 	}
@@ -22,6 +22,8 @@ import (
 	"github.com/bestchains/bestchains-contracts/contracts/nonce"
 	"github.com/bestchains/bestchains-contracts/library"
 	"github.com/bestchains/bestchains-contracts/library/context"
+	safemath "github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/bestchains/bestchains-contracts/library/state"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/pkg/errors"
 )
@@ -44,14 +46,20 @@ type ERC1155 struct {
 	contractapi.Contract
 
 	nonce.INonce
+
+	hooks *context.Hooks
 }
 
-func NewERC1155(nonce nonce.INonce) *ERC1155 {
+func NewERC1155(nonce nonce.INonce, opts ...context.HookOption) *ERC1155 {
 	erc1155Contract := new(ERC1155)
 
 	erc1155Contract.Name = "org.bestchains.com.ERC1155Contract"
 	erc1155Contract.TransactionContextHandler = new(context.Context)
-	erc1155Contract.BeforeTransaction = context.BeforeTransaction
+	erc1155Contract.hooks = context.NewHooks(opts...)
+	erc1155Contract.hooks.Register(erc1155Contract)
+	erc1155Contract.hooks.CapSpending()
+	erc1155Contract.BeforeTransaction = erc1155Contract.hooks.BeforeTransaction
+	erc1155Contract.AfterTransaction = erc1155Contract.hooks.AfterTransaction
 
 	erc1155Contract.INonce = nonce
 
@@ -62,27 +70,28 @@ func (erc1155 *ERC1155) Initialize(ctx context.ContextInterface, name string, sy
 	return nil
 }
 
+// MessageVersion returns the message version clients should sign with
+func (erc1155 *ERC1155) MessageVersion(ctx context.ContextInterface) (uint8, error) {
+	return erc1155.hooks.MessageVersion(ctx)
+}
+
 /* ISupply */
 
-func (erc1155 *ERC1155) TotalSupply(ctx context.ContextInterface, id ID) (uint64, error) {
-	idString := strconv.FormatUint(uint64(id), 10)
-	supplyKey, err := ctx.GetStub().CreateCompositeKey(SupplyPrefix, []string{idString})
-	if err != nil {
-		return 0, errors.Wrap(library.ErrInvalidCompositeKey, err.Error())
-	}
-	supply, err := ctx.GetStub().GetState(supplyKey)
+// TotalSupply returns the total supply of the token id in decimal
+func (erc1155 *ERC1155) TotalSupply(ctx context.ContextInterface, id ID) (string, error) {
+	supply, err := ctx.State().GetUint256(SupplyPrefix, id.String())
 	if err != nil {
-		return 0, err
+		return "", err
 	}
-	return library.BytesToUint64(supply)
+	return supply.String(), nil
 }
 
 func (erc1155 *ERC1155) Exists(ctx context.ContextInterface, id ID) (bool, error) {
-	total, err := erc1155.TotalSupply(ctx, id)
+	supply, err := ctx.State().GetUint256(SupplyPrefix, id.String())
 	if err != nil {
 		return false, err
 	}
-	return total > 0, nil
+	return !supply.IsZero(), nil
 }
 
 /* IERC1155 */
@@ -95,7 +104,7 @@ func (erc1155 *ERC1155) SetURI(ctx context.ContextInterface, id ID, uri string)
 	if err != nil {
 		return errors.Wrap(library.ErrInvalidCompositeKey, err.Error())
 	}
-	_, err = ctx.GetStub().GetState(uriKey)
+	_, err = ctx.State().Get(uriKey)
 	if err != nil {
 		return err
 	}
@@ -109,7 +118,7 @@ func (erc1155 *ERC1155) URI(ctx context.ContextInterface, id ID) (string, error)
 		return "", errors.Wrap(err, "")
 	}
 
-	uri, err := ctx.GetStub().GetState(uriKey)
+	uri, err := ctx.State().Get(uriKey)
 	if err != nil {
 		return "", errors.Wrap(err, "")
 	}
@@ -117,24 +126,25 @@ func (erc1155 *ERC1155) URI(ctx context.ContextInterface, id ID) (string, error)
 	return string(uri), nil
 }
 
-func (erc1155 *ERC1155) BalanceOf(ctx context.ContextInterface, account string, id ID) (uint64, error) {
-	balanceKey, err := ctx.GetStub().CreateCompositeKey(BalancePrefix, []string{account, id.String()})
+// BalanceOf returns the balance of the token id of the account in decimal
+func (erc1155 *ERC1155) BalanceOf(ctx context.ContextInterface, account string, id ID) (string, error) {
+	accountAddr, err := library.ParseAddress(account)
 	if err != nil {
-		return 0, errors.Wrap(library.ErrInvalidCompositeKey, err.Error())
+		return "", err
 	}
-	balance, err := ctx.GetStub().GetState(balanceKey)
+	balance, err := ctx.State().GetUint256(BalancePrefix, accountAddr.String(), id.String())
 	if err != nil {
-		return 0, err
+		return "", err
 	}
-	return library.BytesToUint64(balance)
+	return balance.String(), nil
 }
 
-func (erc1155 *ERC1155) BalanceOfBatch(ctx context.ContextInterface, accounts []string, ids []ID) ([]uint64, error) {
+func (erc1155 *ERC1155) BalanceOfBatch(ctx context.ContextInterface, accounts []string, ids []ID) ([]string, error) {
 	if len(accounts) != len(ids) {
 		return nil, errors.New("accounts and ids must have the same length")
 	}
 
-	balances := make([]uint64, len(accounts))
+	balances := make([]string, len(accounts))
 	for index, account := range accounts {
 		balance, err := erc1155.BalanceOf(ctx, account, ids[index])
 		if err != nil {
@@ -147,29 +157,24 @@ func (erc1155 *ERC1155) BalanceOfBatch(ctx context.ContextInterface, accounts []
 }
 
 // Mint by operator
-func (erc1155 *ERC1155) Mint(ctx context.ContextInterface, to string, id ID, amount uint64) error {
-	var err error
-
-	toAddr := library.Address(to)
-
-	if err = erc1155.beforeTokenTransfer(ctx, library.ZeroAddress, toAddr, []ID{id}, []uint64{amount}); err != nil {
+func (erc1155 *ERC1155) Mint(ctx context.ContextInterface, to string, id ID, amount string) error {
+	toAddr, err := library.ParseAddress(to)
+	if err != nil {
 		return err
 	}
-
-	balanceKey, err := ctx.GetStub().CreateCompositeKey(BalancePrefix, []string{to, id.String()})
-	if err != nil {
+	if err = toAddr.Validate(); err != nil {
 		return err
 	}
-	val, err := ctx.GetStub().GetState(balanceKey)
+	value, err := safemath.ParseUint256(amount)
 	if err != nil {
 		return err
 	}
-	preBalance, err := library.BytesToUint64(val)
-	if err != nil {
+
+	if err = erc1155.beforeTokenTransfer(ctx, library.ZeroAddress, toAddr, []ID{id}, []safemath.Uint256{value}); err != nil {
 		return err
 	}
-	err = ctx.GetStub().PutState(balanceKey, []byte(library.Uint64ToString(amount+preBalance)))
-	if err != nil {
+
+	if err = mint(ctx, toAddr, id, value); err != nil {
 		return err
 	}
 
@@ -178,55 +183,67 @@ func (erc1155 *ERC1155) Mint(ctx context.ContextInterface, to string, id ID, amo
 		From:     library.ZeroAddress,
 		To:       toAddr,
 		ID:       id,
-		Value:    amount,
+		Value:    value,
 	}); err != nil {
 		return errors.Wrap(err, "Event TransferSingle")
 	}
 
-	if err = erc1155.afterTokenTransfer(ctx, library.ZeroAddress, toAddr, []ID{id}, []uint64{amount}); err != nil {
+	if err = erc1155.afterTokenTransfer(ctx, library.ZeroAddress, toAddr, []ID{id}, []safemath.Uint256{value}); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (erc1155 *ERC1155) beforeTokenTransfer(ctx context.ContextInterface, from library.Address, to library.Address, ids []ID, amounts []uint64) error {
+// mint adds amount to the balance of the account and the supply of the token id
+func mint(ctx context.ContextInterface, to library.Address, id ID, amount safemath.Uint256) error {
+	if err := updateBalance(ctx, to, id, amount, safemath.Uint256.TryAdd); err != nil {
+		return err
+	}
+	supply, err := ctx.State().GetUint256(SupplyPrefix, id.String())
+	if err != nil {
+		return err
+	}
+	ok, supply := supply.TryAdd(amount)
+	if !ok {
+		return safemath.ErrMathOpOverflowed
+	}
+	return ctx.State().PutUint256(supply, SupplyPrefix, id.String())
+}
+
+func (erc1155 *ERC1155) beforeTokenTransfer(ctx context.ContextInterface, from library.Address, to library.Address, ids []ID, amounts []safemath.Uint256) error {
 	if len(ids) != len(amounts) {
 		return ErrLengthMismatch
 	}
 	return nil
 }
 
-func (erc1155 *ERC1155) afterTokenTransfer(ctx context.ContextInterface, from library.Address, to library.Address, ids []ID, amounts []uint64) error {
+func (erc1155 *ERC1155) afterTokenTransfer(ctx context.ContextInterface, from library.Address, to library.Address, ids []ID, amounts []safemath.Uint256) error {
 	return nil
 }
 
-func (erc1155 *ERC1155) MintBatch(ctx context.ContextInterface, to string, ids []ID, amounts []uint64) error {
-	var err error
-
-	toAddr := library.Address(to)
-	if err = erc1155.beforeTokenTransfer(ctx, library.ZeroAddress, toAddr, ids, amounts); err != nil {
+func (erc1155 *ERC1155) MintBatch(ctx context.ContextInterface, to string, ids []ID, amounts []string) error {
+	toAddr, err := library.ParseAddress(to)
+	if err != nil {
 		return err
 	}
-
-	// batch mint logic
-	for index, id := range ids {
-		balanceKey, err := ctx.GetStub().CreateCompositeKey(BalancePrefix, []string{to, id.String()})
-		if err != nil {
+	if err = toAddr.Validate(); err != nil {
+		return err
+	}
+	values := make([]safemath.Uint256, len(amounts))
+	for index, amount := range amounts {
+		if values[index], err = safemath.ParseUint256(amount); err != nil {
 			return err
 		}
+	}
 
-		val, err := ctx.GetStub().GetState(balanceKey)
-		if err != nil {
-			return err
-		}
-		preBalance, err := library.BytesToUint64(val)
-		if err != nil {
-			return err
-		}
+	if err = erc1155.beforeTokenTransfer(ctx, library.ZeroAddress, toAddr, ids, values); err != nil {
+		return err
+	}
 
-		err = ctx.GetStub().PutState(balanceKey, []byte(library.Uint64ToString(amounts[index]+preBalance)))
-		if err != nil {
+	// batch mint logic
+	for index, id := range ids {
+		if err = mint(ctx, toAddr, id, values[index]); err != nil {
 			return err
 		}
 	}
@@ -236,47 +253,58 @@ func (erc1155 *ERC1155) MintBatch(ctx context.ContextInterface, to string, ids [
 		From:     library.ZeroAddress,
 		To:       toAddr,
 		IDs:      ids,
-		Values:   amounts,
+		Values:   values,
 	}); err != nil {
 		return errors.Wrap(err, "Event TransferBatch")
 	}
 
-	if err = erc1155.afterTokenTransfer(ctx, library.ZeroAddress, toAddr, ids, amounts); err != nil {
+	if err = erc1155.afterTokenTransfer(ctx, library.ZeroAddress, toAddr, ids, values); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// SetApprovalForAll approves the operator to transfer all tokens of the message sender,or revokes it.
+// - a session key can only revoke,as an approved operator is not bound by its spending cap
 func (erc1155 *ERC1155) SetApprovalForAll(ctx context.ContextInterface, msg context.Message, operator string, approved bool) error {
-	var err error
+	if approved && ctx.MsgSigner() != ctx.MsgSender() {
+		return ErrDelegatedApproval
+	}
 
-	// Nonce Check & Increase
-	if err = erc1155.INonce.Check(ctx, ctx.MsgSender().String(), msg.Nonce); err != nil {
+	operatorAddr, err := library.ParseAddress(operator)
+	if err != nil {
 		return err
 	}
-	if _, err = erc1155.INonce.Increment(ctx, ctx.MsgSender().String()); err != nil {
+	if err = operatorAddr.Validate(); err != nil {
 		return err
 	}
 
 	// Approve
-	approvalKey, err := ctx.GetStub().CreateCompositeKey(ApprovalPrefix, []string{ctx.MsgSender().String(), operator})
+	approvalKey, err := ctx.GetStub().CreateCompositeKey(ApprovalPrefix, []string{ctx.MsgSender().String(), operatorAddr.String()})
 	if err != nil {
 		return err
 	}
-	if err = ctx.GetStub().PutState(approvalKey, []byte("Approved")); err != nil {
-		return err
+	if !approved {
+		return ctx.State().Delete(approvalKey)
 	}
-
-	return nil
+	return ctx.State().Put(approvalKey, []byte("Approved"))
 }
 
 func (erc1155 *ERC1155) IsApprovedFroAll(ctx context.ContextInterface, account string, operator string) (bool, error) {
-	approvalKey, err := ctx.GetStub().CreateCompositeKey(ApprovalPrefix, []string{account, operator})
+	accountAddr, err := library.ParseAddress(account)
 	if err != nil {
 		return false, err
 	}
-	approved, err := ctx.GetStub().GetState(approvalKey)
+	operatorAddr, err := library.ParseAddress(operator)
+	if err != nil {
+		return false, err
+	}
+	approvalKey, err := ctx.GetStub().CreateCompositeKey(ApprovalPrefix, []string{accountAddr.String(), operatorAddr.String()})
+	if err != nil {
+		return false, err
+	}
+	approved, err := ctx.State().Get(approvalKey)
 	if err != nil {
 		return false, err
 	}
@@ -288,14 +316,187 @@ func (erc1155 *ERC1155) IsApprovedFroAll(ctx context.ContextInterface, account s
 	return true, nil
 }
 
-func (erc1155 *ERC1155) SafeTransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, id ID, amount uint64) error {
-	// TODO: permission check
+// SafeTransferFrom transfers amount of the token id from the "from" address to the "to" address
+// - the message sender must be "from" or an operator approved by it
+// This function triggers a TransferSingle event
+func (erc1155 *ERC1155) SafeTransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, id ID, amount string) error {
+	value, err := safemath.ParseUint256(amount)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	fromAddr, toAddr, err := erc1155.transfer(ctx, from, to, []ID{id}, []safemath.Uint256{value})
+	if err != nil {
+		return err
+	}
+
+	if err = ctx.EmitEvent("TransferSingle", &EventTransferSingle{
+		Operator: ctx.MsgSender(),
+		From:     fromAddr,
+		To:       toAddr,
+		ID:       id,
+		Value:    value,
+	}); err != nil {
+		return errors.Wrap(err, "Event TransferSingle")
+	}
+
+	return erc1155.afterTokenTransfer(ctx, fromAddr, toAddr, []ID{id}, []safemath.Uint256{value})
 }
 
-func (erc1155 *ERC1155) SafeBatchTransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, ids []uint64, amounts []uint64) error {
-	// TODO: permission check
+// SafeBatchTransferFrom transfers amounts of the token ids from the "from" address to the "to" address
+// - the message sender must be "from" or an operator approved by it
+// This function triggers a TransferBatch event
+func (erc1155 *ERC1155) SafeBatchTransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, ids []uint64, amounts []string) error {
+	tokenIDs := make([]ID, len(ids))
+	for index, id := range ids {
+		tokenIDs[index] = ID(id)
+	}
+	values := make([]safemath.Uint256, len(amounts))
+	var err error
+	for index, amount := range amounts {
+		if values[index], err = safemath.ParseUint256(amount); err != nil {
+			return err
+		}
+	}
+
+	fromAddr, toAddr, err := erc1155.transfer(ctx, from, to, tokenIDs, values)
+	if err != nil {
+		return err
+	}
+
+	if err = ctx.EmitEvent("TransferBatch", &EventTransferBatch{
+		Operator: ctx.MsgSender(),
+		From:     fromAddr,
+		To:       toAddr,
+		IDs:      tokenIDs,
+		Values:   values,
+	}); err != nil {
+		return errors.Wrap(err, "Event TransferBatch")
+	}
+
+	return erc1155.afterTokenTransfer(ctx, fromAddr, toAddr, tokenIDs, values)
+}
+
+// transfer moves amounts of the token ids from the "from" address to the "to" address for the message sender.
+// A session key spends its spending cap of each token id on its own.
+func (erc1155 *ERC1155) transfer(ctx context.ContextInterface, from string, to string, ids []ID, amounts []safemath.Uint256) (library.Address, library.Address, error) {
+	fromAddr, err := library.ParseAddress(from)
+	if err != nil {
+		return library.ZeroAddress, library.ZeroAddress, err
+	}
+	if err = fromAddr.Validate(); err != nil {
+		return library.ZeroAddress, library.ZeroAddress, err
+	}
+	toAddr, err := library.ParseAddress(to)
+	if err != nil {
+		return library.ZeroAddress, library.ZeroAddress, err
+	}
+	if err = toAddr.Validate(); err != nil {
+		return library.ZeroAddress, library.ZeroAddress, err
+	}
+
+	if !fromAddr.Equal(ctx.MsgSender()) {
+		approved, err := erc1155.IsApprovedFroAll(ctx, fromAddr.String(), ctx.MsgSender().String())
+		if err != nil {
+			return library.ZeroAddress, library.ZeroAddress, err
+		}
+		if !approved {
+			return library.ZeroAddress, library.ZeroAddress, ErrNotOwnerNorApproved
+		}
+	}
+
+	if err = erc1155.beforeTokenTransfer(ctx, fromAddr, toAddr, ids, amounts); err != nil {
+		return library.ZeroAddress, library.ZeroAddress, err
+	}
+
+	for index, id := range ids {
+		if err = erc1155.hooks.Spend(ctx, id.String(), amounts[index]); err != nil {
+			return library.ZeroAddress, library.ZeroAddress, err
+		}
+		// The receiver's balance is read after the sender's balance is updated,
+		// so transferring to oneself keeps the balance.
+		if err = updateBalance(ctx, fromAddr, id, amounts[index], safemath.Uint256.TrySub); err != nil {
+			return library.ZeroAddress, library.ZeroAddress, errors.Wrapf(err, "transferred more than it has of token %s", id)
+		}
+		if err = updateBalance(ctx, toAddr, id, amounts[index], safemath.Uint256.TryAdd); err != nil {
+			return library.ZeroAddress, library.ZeroAddress, err
+		}
+	}
+
+	return fromAddr, toAddr, nil
+}
+
+// TransferFromSelf transfers tokens held by the invoked contract's own address(`ctx.Self()`) to the recipient.
+// It is not a transaction but a function for contracts holding tokens,
+// which move them by their own logic without a signature of the holder.
+// This function triggers a TransferSingle event
+func TransferFromSelf(ctx context.ContextInterface, to string, id ID, amount safemath.Uint256) error {
+	toAddr, err := library.ParseAddress(to)
+	if err != nil {
+		return err
+	}
+	if err = toAddr.Validate(); err != nil {
+		return err
+	}
+	fromAddr := ctx.Self()
+	if err = fromAddr.Validate(); err != nil {
+		return errors.Wrap(err, "invalid contract address")
+	}
+
+	// The receiver's balance is read after the sender's balance is updated,
+	// so transferring to oneself keeps the balance.
+	if err = updateBalance(ctx, fromAddr, id, amount, safemath.Uint256.TrySub); err != nil {
+		return errors.Wrap(err, "transferred more than it has")
+	}
+	if err = updateBalance(ctx, toAddr, id, amount, safemath.Uint256.TryAdd); err != nil {
+		return err
+	}
+
+	if err = ctx.EmitEvent("TransferSingle", &EventTransferSingle{
+		Operator: fromAddr,
+		From:     fromAddr,
+		To:       toAddr,
+		ID:       id,
+		Value:    amount,
+	}); err != nil {
+		return errors.Wrap(err, "Event TransferSingle")
+	}
 
 	return nil
 }
+
+// updateBalance updates the balance of account by op with amount
+func updateBalance(ctx context.ContextInterface, account library.Address, id ID, amount safemath.Uint256, op func(x, y safemath.Uint256) (bool, safemath.Uint256)) error {
+	balance, err := ctx.State().GetUint256(BalancePrefix, account.String(), id.String())
+	if err != nil {
+		return err
+	}
+	ok, balance := op(balance, amount)
+	if !ok {
+		return safemath.ErrMathOpOverflowed
+	}
+	return ctx.State().PutUint256(balance, BalancePrefix, account.String(), id.String())
+}
+
+// MigrateAddresses moves the balances and approvals kept under mixed case addresses,
+// which were stored before addresses were canonical,to the keys of their canonical addresses.
+// - balances of the same account are summed,so the supply is kept
+// - an approval kept under the canonical addresses is newer than the moved one,so it is kept
+// Anyone can run it as it never moves tokens between accounts. It returns the number of keys moved,which is 0 once migrated
+func (erc1155 *ERC1155) MigrateAddresses(ctx context.ContextInterface) (uint64, error) {
+	var count uint64
+	for _, migration := range []struct {
+		objectType string
+		merge      func(moved []byte, current []byte) ([]byte, error)
+	}{
+		{BalancePrefix, state.SumUint256},
+		{ApprovalPrefix, state.KeepCurrent},
+	} {
+		moved, err := ctx.State().MigrateKeys(migration.objectType, library.CanonicalAttributes, migration.merge)
+		if err != nil {
+			return count, errors.Wrapf(err, "migrate %s", migration.objectType)
+		}
+		count += moved
+	}
+	return count, nil
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package erc1155
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/contracts/delegation"
+	"github.com/bestchains/bestchains-contracts/contracts/nonce"
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/context/contexttest"
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/stretchr/testify/assert"
+)
+
+// assertBalance asserts the balance of the token id of account in decimal
+func assertBalance(t *testing.T, cc *contexttest.Chaincode, account string, id string, expected string) {
+	balance, err := cc.Invoke(nil, "BalanceOf", account, id)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, balance)
+}
+
+// maxUint256 is 2^256-1 in decimal
+const maxUint256 = "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+
+// assertSupply asserts the total supply of the token id in decimal
+func assertSupply(t *testing.T, cc *contexttest.Chaincode, id string, expected string) {
+	supply, err := cc.Invoke(nil, "TotalSupply", id)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, supply)
+}
+
+func TestERC1155(t *testing.T) {
+	cc := contexttest.NewChaincode(t, "erc1155", NewERC1155(nonce.NewNonceContract()))
+	alice, bob, carol := contexttest.NewAccount(t), contexttest.NewAccount(t), contexttest.NewAccount(t)
+
+	t.Run("Amounts are decimal Uint256 strings", func(t *testing.T) {
+		// 2^64 does not fit in uint64
+		assert.NoError(t, cc.Submit(alice, "Mint", alice.String(), "1", "18446744073709551616"))
+		assertBalance(t, cc, alice.String(), "1", "18446744073709551616")
+		assertSupply(t, cc, "1", "18446744073709551616")
+
+		for _, amount := range []string{"", "-1", "0x10", "1.5", "1e3", maxUint256 + "0"} {
+			assert.Error(t, cc.Submit(alice, "Mint", alice.String(), "1", amount), amount)
+		}
+		assertSupply(t, cc, "1", "18446744073709551616")
+
+		// a balance stored as uint64 is decoded as it is
+		key, err := cc.Stub.CreateCompositeKey(BalancePrefix, []string{carol.String(), "9"})
+		assert.NoError(t, err)
+		cc.Stub.MockTransactionStart("legacy")
+		assert.NoError(t, cc.Stub.PutState(key, []byte("42")))
+		cc.Stub.MockTransactionEnd("legacy")
+		assertBalance(t, cc, carol.String(), "9", "42")
+	})
+
+	t.Run("Mint tracks the supply of each token", func(t *testing.T) {
+		exists, err := cc.Invoke(nil, "Exists", "2")
+		assert.NoError(t, err)
+		assert.Equal(t, false, exists)
+
+		assert.NoError(t, cc.Submit(alice, "MintBatch", bob.String(), "[2,3,2]", `["10","20","5"]`))
+		assert.Equal(t, "TransferBatch", cc.Events()[0].Name)
+		assertBalance(t, cc, bob.String(), "2", "15")
+		assertBalance(t, cc, bob.String(), "3", "20")
+		assertSupply(t, cc, "2", "15")
+		assertSupply(t, cc, "3", "20")
+
+		assert.NoError(t, cc.Submit(alice, "Mint", carol.String(), "2", "1"))
+		assertSupply(t, cc, "2", "16")
+		exists, err = cc.Invoke(nil, "Exists", "2")
+		assert.NoError(t, err)
+		assert.Equal(t, true, exists)
+
+		balances, err := cc.Invoke(nil, "BalanceOfBatch", `["`+bob.String()+`","`+carol.String()+`"]`, "[2,2]")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"15", "1"}, balances)
+
+		assert.ErrorIs(t, cc.Submit(alice, "MintBatch", bob.String(), "[2,3]", `["1"]`), ErrLengthMismatch)
+		assertSupply(t, cc, "2", "16")
+	})
+
+	t.Run("Overflows are rejected", func(t *testing.T) {
+		assert.NoError(t, cc.Submit(alice, "Mint", alice.String(), "4", maxUint256))
+		assertSupply(t, cc, "4", maxUint256)
+
+		// the balance overflows
+		assert.ErrorIs(t, cc.Submit(alice, "Mint", alice.String(), "4", "1"), math.ErrMathOpOverflowed)
+		// the supply overflows while the balance does not
+		assert.ErrorIs(t, cc.Submit(alice, "Mint", bob.String(), "4", "1"), math.ErrMathOpOverflowed)
+		// a batch fails as a whole
+		assert.ErrorIs(t, cc.Submit(alice, "MintBatch", bob.String(), "[5,4]", `["1","1"]`), math.ErrMathOpOverflowed)
+
+		assertBalance(t, cc, alice.String(), "4", maxUint256)
+		assertBalance(t, cc, bob.String(), "4", "0")
+		assertSupply(t, cc, "4", maxUint256)
+		assertSupply(t, cc, "5", "0")
+	})
+
+	t.Run("Transfers move balances and keep supplies", func(t *testing.T) {
+		assert.NoError(t, cc.Submit(bob, "SafeTransferFrom", bob.String(), carol.String(), "2", "5"))
+		assert.JSONEq(t, `{"operator":"`+bob.String()+`","from":"`+bob.String()+`","to":"`+carol.String()+`","id":2,"value":"5"}`, string(cc.Events()[0].Payload))
+		assertBalance(t, cc, bob.String(), "2", "10")
+		assertBalance(t, cc, carol.String(), "2", "6")
+		assertSupply(t, cc, "2", "16")
+
+		assert.NoError(t, cc.Submit(bob, "SafeBatchTransferFrom", bob.String(), carol.String(), "[2,3]", `["10","20"]`))
+		assert.Equal(t, "TransferBatch", cc.Events()[0].Name)
+		assertBalance(t, cc, bob.String(), "2", "0")
+		assertBalance(t, cc, bob.String(), "3", "0")
+		assertBalance(t, cc, carol.String(), "2", "16")
+		assertBalance(t, cc, carol.String(), "3", "20")
+		assertSupply(t, cc, "3", "20")
+
+		// transfers beyond the balance,or to a full balance,fail as a whole
+		assert.ErrorIs(t, cc.Submit(carol, "SafeBatchTransferFrom", carol.String(), bob.String(), "[2,3]", `["16","21"]`), math.ErrMathOpOverflowed)
+		assert.NoError(t, cc.Submit(alice, "Mint", bob.String(), "6", maxUint256))
+		assert.NoError(t, cc.Submit(alice, "Mint", carol.String(), "7", "1"))
+		assert.ErrorIs(t, cc.Submit(carol, "SafeTransferFrom", carol.String(), bob.String(), "6", "1"), math.ErrMathOpOverflowed)
+		assertBalance(t, cc, carol.String(), "2", "16")
+		assertBalance(t, cc, carol.String(), "3", "20")
+		assertBalance(t, cc, bob.String(), "2", "0")
+
+		assert.ErrorIs(t, cc.Submit(carol, "SafeTransferFrom", carol.String(), library.ZeroAddress.String(), "2", "1"), library.ErrInvalidAddressNull)
+	})
+
+	t.Run("Only the owner and its approved operators transfer", func(t *testing.T) {
+		assert.ErrorIs(t, cc.Submit(bob, "SafeTransferFrom", carol.String(), bob.String(), "2", "1"), ErrNotOwnerNorApproved)
+
+		assert.NoError(t, cc.Submit(carol, "SetApprovalForAll", bob.String(), "true"))
+		assert.NoError(t, cc.Submit(bob, "SafeTransferFrom", carol.String(), bob.String(), "2", "1"))
+		assert.JSONEq(t, `{"operator":"`+bob.String()+`","from":"`+carol.String()+`","to":"`+bob.String()+`","id":2,"value":"1"}`, string(cc.Events()[0].Payload))
+		assertBalance(t, cc, bob.String(), "2", "1")
+
+		assert.NoError(t, cc.Submit(carol, "SetApprovalForAll", bob.String(), "false"))
+		approved, err := cc.Invoke(nil, "IsApprovedFroAll", carol.String(), bob.String())
+		assert.NoError(t, err)
+		assert.Equal(t, false, approved)
+		assert.ErrorIs(t, cc.Submit(bob, "SafeTransferFrom", carol.String(), bob.String(), "2", "1"), ErrNotOwnerNorApproved)
+	})
+}
+
+func TestSpendingCap(t *testing.T) {
+	nonceContract := nonce.NewNonceContract()
+	erc1155 := NewERC1155(nonceContract, context.WithDelegationStore(context.LedgerDelegationStore{}))
+	cc := contexttest.NewChaincode(t, "erc1155", erc1155, delegation.NewDelegationContract(nonceContract))
+	cc.Now = 1000
+	alice, bob := contexttest.NewAccount(t), contexttest.NewAccount(t)
+	sessionKey := alice.SessionKey(t)
+
+	assert.NoError(t, cc.Submit(alice, "MintBatch", alice.String(), "[1,2]", `["100","100"]`))
+	assert.NoError(t, cc.Submit(alice, "org.bestchains.com.DelegationContract:Delegate", sessionKey.String(), `["SafeTransferFrom","SafeBatchTransferFrom"]`, "10", "1100"))
+
+	// the cap of each token id is spent on its own
+	assert.NoError(t, cc.Submit(sessionKey, "SafeTransferFrom", alice.String(), bob.String(), "1", "6"))
+	assert.ErrorIs(t, cc.Submit(sessionKey, "SafeTransferFrom", alice.String(), bob.String(), "1", "5"), context.ErrSpendingCapExceeded)
+	assert.NoError(t, cc.Submit(sessionKey, "SafeTransferFrom", alice.String(), bob.String(), "2", "10"))
+
+	// a batch spends the caps of all its tokens,including the same token twice
+	assert.ErrorIs(t, cc.Submit(sessionKey, "SafeBatchTransferFrom", alice.String(), bob.String(), "[1,1]", `["3","2"]`), context.ErrSpendingCapExceeded)
+	assert.NoError(t, cc.Submit(sessionKey, "SafeBatchTransferFrom", alice.String(), bob.String(), "[1,1]", `["2","2"]`))
+	assertBalance(t, cc, alice.String(), "1", "90")
+	assertBalance(t, cc, alice.String(), "2", "90")
+	assertBalance(t, cc, bob.String(), "1", "10")
+
+	// an approved operator would not be capped
+	assert.NoError(t, cc.Submit(alice, "org.bestchains.com.DelegationContract:Delegate", sessionKey.String(), `["SetApprovalForAll"]`, "10", "1100"))
+	assert.ErrorIs(t, cc.Submit(sessionKey, "SetApprovalForAll", bob.String(), "true"), ErrDelegatedApproval)
+	assert.NoError(t, cc.Submit(sessionKey, "SetApprovalForAll", bob.String(), "false"))
+
+	// the sender itself is not capped
+	assert.NoError(t, cc.Submit(alice, "SafeTransferFrom", alice.String(), bob.String(), "1", "50"))
+	assertBalance(t, cc, bob.String(), "1", "60")
+}
+
+func TestTransferFromSelf(t *testing.T) {
+	cc := contexttest.NewChaincode(t, "erc1155", NewERC1155(nonce.NewNonceContract()))
+	alice, bob := contexttest.NewAccount(t), contexttest.NewAccount(t)
+
+	// transferFromSelf transfers amount of the token id from the contract's own address in a transaction of alice
+	var self library.Address
+	transferFromSelf := func(to string, id ID, amount uint64) error {
+		return cc.Run(alice, "", false, nil, func(ctx *context.Context, _ *context.Message) error {
+			self = ctx.Self()
+			return TransferFromSelf(ctx, to, id, math.NewUint256(amount))
+		})
+	}
+
+	// the contract holds the tokens minted to its own address
+	assert.NoError(t, transferFromSelf(bob.String(), 1, 0))
+	assert.NoError(t, cc.Submit(alice, "Mint", self.String(), "1", "100"))
+	assert.NoError(t, cc.Submit(alice, "Mint", self.String(), "2", "5"))
+
+	t.Run("Moves the tokens of the contract", func(t *testing.T) {
+		assert.NoError(t, transferFromSelf(bob.String(), 1, 30))
+		events := cc.Events()
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, "TransferSingle", events[0].Name)
+			assert.JSONEq(t, `{"operator":"`+self.String()+`","from":"`+self.String()+`","to":"`+bob.String()+`","id":1,"value":"30"}`, string(events[0].Payload))
+		}
+		assertBalance(t, cc, self.String(), "1", "70")
+		assertBalance(t, cc, bob.String(), "1", "30")
+
+		// the other token is kept
+		assertBalance(t, cc, self.String(), "2", "5")
+		assertBalance(t, cc, bob.String(), "2", "0")
+
+		// transfers keep the supply
+		supply, err := cc.Invoke(nil, "TotalSupply", "1")
+		assert.NoError(t, err)
+		assert.Equal(t, "100", supply)
+	})
+
+	t.Run("Rejects the zero address", func(t *testing.T) {
+		assert.ErrorIs(t, transferFromSelf(library.ZeroAddress.String(), 1, 1), library.ErrInvalidAddressNull)
+		assertBalance(t, cc, self.String(), "1", "70")
+	})
+
+	t.Run("Rejects transfers beyond the balance", func(t *testing.T) {
+		assert.ErrorIs(t, transferFromSelf(bob.String(), 1, 71), math.ErrMathOpOverflowed)
+		assert.ErrorIs(t, transferFromSelf(bob.String(), 3, 1), math.ErrMathOpOverflowed)
+		assertBalance(t, cc, self.String(), "1", "70")
+		assertBalance(t, cc, bob.String(), "1", "30")
+
+		assert.NoError(t, transferFromSelf(bob.String(), 1, 70))
+		assertBalance(t, cc, self.String(), "1", "0")
+		assertBalance(t, cc, bob.String(), "1", "100")
+	})
+}
+
+func TestMigrateAddresses(t *testing.T) {
+	cc := contexttest.NewChaincode(t, "erc1155", NewERC1155(nonce.NewNonceContract()))
+	alice, bob := contexttest.NewAccount(t), contexttest.NewAccount(t)
+	upper := func(account *contexttest.Account) string {
+		return library.AddressPrefix + strings.ToUpper(account.String()[2:])
+	}
+
+	assert.NoError(t, cc.Submit(alice, "Mint", alice.String(), "1", "100"))
+
+	// keys stored before addresses were canonical
+	legacy := []struct {
+		key   []string
+		value string
+	}{
+		{[]string{BalancePrefix, alice.Address.Checksum(), "1"}, "40"},
+		{[]string{BalancePrefix, upper(alice), "1"}, "2"},
+		{[]string{BalancePrefix, alice.Address.Checksum(), "2"}, "7"},
+		{[]string{ApprovalPrefix, upper(alice), bob.Address.Checksum()}, "Approved"},
+	}
+	cc.Stub.MockTransactionStart("legacy")
+	for _, entry := range legacy {
+		key, err := cc.Stub.CreateCompositeKey(entry.key[0], entry.key[1:])
+		assert.NoError(t, err)
+		assert.NoError(t, cc.Stub.PutState(key, []byte(entry.value)))
+	}
+	cc.Stub.MockTransactionEnd("legacy")
+
+	t.Run("Moves the legacy keys to the canonical ones", func(t *testing.T) {
+		count, err := cc.Invoke(bob, "MigrateAddresses")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(4), count)
+
+		// balances of the same account and token are summed
+		assertBalance(t, cc, alice.String(), "1", "142")
+		assertBalance(t, cc, alice.String(), "2", "7")
+		approved, err := cc.Invoke(nil, "IsApprovedFroAll", alice.String(), bob.String())
+		assert.NoError(t, err)
+		assert.Equal(t, true, approved)
+
+		for _, entry := range legacy {
+			key, err := cc.Stub.CreateCompositeKey(entry.key[0], entry.key[1:])
+			assert.NoError(t, err)
+			value, err := cc.Stub.GetState(key)
+			assert.NoError(t, err)
+			assert.Nil(t, value)
+		}
+	})
+
+	t.Run("Runs again without moving anything", func(t *testing.T) {
+		count, err := cc.Invoke(alice, "MigrateAddresses")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), count)
+		assertBalance(t, cc, alice.String(), "1", "142")
+	})
+}
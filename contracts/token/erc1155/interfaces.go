@@ -22,11 +22,15 @@ import (
 	"github.com/bestchains/bestchains-contracts/contracts/nonce"
 	"github.com/bestchains/bestchains-contracts/library"
 	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/math"
 )
 
 var (
-	ErrTokenAlreadyExist = errors.New("token already exists")
-	ErrLengthMismatch    = errors.New("ids and amounts must have same length")
+	ErrTokenAlreadyExist   = errors.New("token already exists")
+	ErrLengthMismatch      = errors.New("ids and amounts must have same length")
+	ErrNotOwnerNorApproved = errors.New("caller is not the owner nor approved")
+	// ErrDelegatedApproval is returned when a session key approves an operator,which no spending cap bounds
+	ErrDelegatedApproval = errors.New("operators can not be approved by a session key")
 )
 
 type ID uint64
@@ -49,7 +53,7 @@ type EventTransferSingle struct {
 	From     library.Address `json:"from"`
 	To       library.Address `json:"to"`
 	ID       ID              `json:"id"`
-	Value    uint64          `json:"value"`
+	Value    math.Uint256    `json:"value"`
 }
 
 type EventTransferBatch struct {
@@ -57,7 +61,7 @@ type EventTransferBatch struct {
 	From     library.Address `json:"from"`
 	To       library.Address `json:"to"`
 	IDs      []ID            `json:"ids"`
-	Values   []uint64        `json:"values"`
+	Values   []math.Uint256  `json:"values"`
 }
 
 type EventApprovalForAll struct {
@@ -74,29 +78,33 @@ type EventURI struct {
 type IERC1155 interface {
 	nonce.INonce
 
+	MessageVersion(ctx context.ContextInterface) (uint8, error)
+
 	SetURI(ctx context.ContextInterface, id ID, uri string) error
 	URI(ctx context.ContextInterface, id ID) (string, error)
 
-	BalanceOf(ctx context.ContextInterface, account string, id ID) (uint64, error)
-	BalanceOfBatch(ctx context.ContextInterface, accounts []string, id []ID) ([]uint64, error)
+	BalanceOf(ctx context.ContextInterface, account string, id ID) (string, error)
+	BalanceOfBatch(ctx context.ContextInterface, accounts []string, id []ID) ([]string, error)
 
-	Mint(ctx context.ContextInterface, to string, id ID, amount uint64) error
-	MintBatch(ctx context.ContextInterface, to string, ids []ID, amount []uint64) error
+	Mint(ctx context.ContextInterface, to string, id ID, amount string) error
+	MintBatch(ctx context.ContextInterface, to string, ids []ID, amount []string) error
 
 	SetApprovalForAll(ctx context.ContextInterface, msg context.Message, operator string, approved bool) error
 	IsApprovedFroAll(ctx context.ContextInterface, account string, operator string) (bool, error)
 
-	SafeTransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, id ID, amount uint64) error
-	SafeBatchTransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, ids []uint64, amounts []uint64) error
+	SafeTransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, id ID, amount string) error
+	SafeBatchTransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, ids []uint64, amounts []string) error
+
+	MigrateAddresses(ctx context.ContextInterface) (uint64, error)
 }
 
 type ISupply interface {
-	TotalSupply(ctx context.ContextInterface, id ID) (uint64, error)
+	TotalSupply(ctx context.ContextInterface, id ID) (string, error)
 	Exists(ctx context.ContextInterface, id ID) (bool, error)
 }
 
 type Burnable interface {
-	Burn(ctx context.ContextInterface, msg context.Message, id ID, amount uint64) error
+	Burn(ctx context.ContextInterface, msg context.Message, id ID, amount string) error
 }
 
 type Pausable interface {
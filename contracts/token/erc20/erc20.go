@@ -21,6 +21,8 @@ import (
 	"github.com/bestchains/bestchains-contracts/contracts/nonce"
 	"github.com/bestchains/bestchains-contracts/library"
 	"github.com/bestchains/bestchains-contracts/library/context"
+	safemath "github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/bestchains/bestchains-contracts/library/state"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/pkg/errors"
 )
@@ -35,11 +37,17 @@ const (
 
 // Define objectType names for prefix
 const (
+	// allowancePrefix keys allowances by owner and spender.
+	// It keeps the objectType of allowances,whose former key nested the approval's composite key
+	// which can never be an attribute of a composite key,so no allowance was stored under it.
 	allowancePrefix = "allowance"
 	ApprovalPrefix  = "approval~account~spender"
 	BalancePrefix   = "balance~account~id"
 )
 
+// approved is the value of an approval
+const approved = "Approved"
+
 // Define key names for options
 
 // ERC20 provides functions for transferring tokens between accounts
@@ -47,12 +55,24 @@ type ERC20 struct {
 	contractapi.Contract
 
 	nonce.INonce
+
+	hooks *context.Hooks
 }
 
-func NewERC20(nonce nonce.INonce) *ERC20 {
-	return &ERC20{
-		INonce: nonce,
-	}
+func NewERC20(nonce nonce.INonce, opts ...context.HookOption) *ERC20 {
+	erc20Contract := new(ERC20)
+
+	erc20Contract.Contract.Name = "org.bestchains.com.ERC20Contract"
+	erc20Contract.TransactionContextHandler = new(context.Context)
+	erc20Contract.hooks = context.NewHooks(opts...)
+	erc20Contract.hooks.Register(erc20Contract)
+	erc20Contract.hooks.CapSpending()
+	erc20Contract.BeforeTransaction = erc20Contract.hooks.BeforeTransaction
+	erc20Contract.AfterTransaction = erc20Contract.hooks.AfterTransaction
+
+	erc20Contract.INonce = nonce
+
+	return erc20Contract
 }
 
 var _ ISupply
@@ -60,39 +80,44 @@ var _ IERC20 = new(ERC20)
 
 // TODO: Initializer
 
-// TotalSupply returns the total token supply
-func (erc20 *ERC20) TotalSupply(ctx context.ContextInterface) (uint64, error) {
-	supply, err := ctx.GetStub().GetState(totalSupplyKey)
+// MessageVersion returns the message version clients should sign with
+func (erc20 *ERC20) MessageVersion(ctx context.ContextInterface) (uint8, error) {
+	return erc20.hooks.MessageVersion(ctx)
+}
+
+// TotalSupply returns the total token supply in decimal
+func (erc20 *ERC20) TotalSupply(ctx context.ContextInterface) (string, error) {
+	supply, err := ctx.State().GetUint256(totalSupplyKey)
 	if err != nil {
-		return 0, err
+		return "", err
 	}
-	return library.BytesToUint64(supply)
+	return supply.String(), nil
 }
 
 // Name returns a descriptive name for fungible tokens in this contract.
 func (erc20 *ERC20) Name(ctx context.ContextInterface) (string, error) {
-	bytes, err := ctx.GetStub().GetState(nameKey)
+	name, err := ctx.State().GetString(nameKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to get Name: %s", err)
 	}
 
-	return string(bytes), nil
+	return name, nil
 }
 
 // Symbol returns an abbreviated name for fungible tokens in this contract.
 func (erc20 *ERC20) Symbol(ctx context.ContextInterface) (string, error) {
-	bytes, err := ctx.GetStub().GetState(symbolKey)
+	symbol, err := ctx.State().GetString(symbolKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to get Symbol: %v", err)
 	}
 
-	return string(bytes), nil
+	return symbol, nil
 }
 
 // Decimal returns the decimal setting of fungible tokens in this contract.
 // For example: if the decimal settings is 2, then transferring 525 tokens will be displayed to users as transferring '5.25 tokens'
 func (erc20 *ERC20) Decimal(ctx context.ContextInterface) (uint8, error) {
-	bytes, err := ctx.GetStub().GetState(decimalsKey)
+	bytes, err := ctx.State().Get(decimalsKey)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get Decimal: %erc20", err)
 	}
@@ -107,38 +132,25 @@ func (erc20 *ERC20) Decimal(ctx context.ContextInterface) (uint8, error) {
 
 // Mint creates new tokens and adds them to minter's account balance
 // This function triggers a Transfer event
-func (erc20 *ERC20) Mint(ctx context.ContextInterface, msg context.Message, to string, amount uint64) error {
-	var err error
-	toAddr := library.Address(to)
-
-	if err = toAddr.Validate(); err != nil {
+func (erc20 *ERC20) Mint(ctx context.ContextInterface, msg context.Message, to string, amount string) error {
+	toAddr, err := library.ParseAddress(to)
+	if err != nil {
 		return err
 	}
-
-	// Nonce Check & Increase
-	if err = erc20.INonce.Check(ctx, ctx.MsgSender().String(), msg.Nonce); err != nil {
+	if err = toAddr.Validate(); err != nil {
 		return err
 	}
-	if _, err = erc20.INonce.Increment(ctx, ctx.MsgSender().String()); err != nil {
+	value, err := safemath.ParseUint256(amount)
+	if err != nil {
 		return err
 	}
 
 	// beforeTokenTransfer
 
-	balanceKey, err := ctx.GetStub().CreateCompositeKey(BalancePrefix, []string{to})
-	if err != nil {
+	if err = addUint256(ctx, value, BalancePrefix, toAddr.String()); err != nil {
 		return err
 	}
-	preVal, err := ctx.GetStub().GetState(balanceKey)
-	if err != nil {
-		return err
-	}
-	preBalance, err := library.BytesToUint64(preVal)
-	if err != nil {
-		return err
-	}
-	err = ctx.GetStub().PutState(balanceKey, []byte(library.Uint64ToString(amount+preBalance)))
-	if err != nil {
+	if err = addUint256(ctx, value, totalSupplyKey); err != nil {
 		return err
 	}
 
@@ -146,7 +158,7 @@ func (erc20 *ERC20) Mint(ctx context.ContextInterface, msg context.Message, to s
 		Operator: ctx.MsgSender(),
 		From:     library.ZeroAddress,
 		To:       toAddr,
-		Value:    amount,
+		Value:    value,
 	}); err != nil {
 		return errors.Wrap(err, "Event TransferSingle")
 	}
@@ -158,43 +170,27 @@ func (erc20 *ERC20) Mint(ctx context.ContextInterface, msg context.Message, to s
 
 // Burn redeems tokens the minter's account balance
 // This function triggers a Transfer event
-func (erc20 *ERC20) Burn(ctx context.ContextInterface, msg context.Message, amount uint64) error {
+func (erc20 *ERC20) Burn(ctx context.ContextInterface, msg context.Message, amount string) error {
 	var err error
 	fromAddr := library.Address(ctx.MsgSender().String())
 
 	if err = fromAddr.Validate(); err != nil {
 		return err
 	}
-
-	// Nonce Check & Increase
-	if err = erc20.INonce.Check(ctx, ctx.MsgSender().String(), msg.Nonce); err != nil {
-		return err
-	}
-	if _, err = erc20.INonce.Increment(ctx, ctx.MsgSender().String()); err != nil {
+	value, err := safemath.ParseUint256(amount)
+	if err != nil {
 		return err
 	}
 
 	// beforeTokenTransfer
 
-	balanceKey, err := ctx.GetStub().CreateCompositeKey(BalancePrefix, []string{ctx.MsgSender().String()})
-	if err != nil {
-		return err
-	}
-	preVal, err := ctx.GetStub().GetState(balanceKey)
-	if err != nil {
+	if err = erc20.hooks.Spend(ctx, "", value); err != nil {
 		return err
 	}
-	preBalance, err := library.BytesToUint64(preVal)
-	if err != nil {
-		return err
+	if err = subUint256(ctx, value, BalancePrefix, fromAddr.String()); err != nil {
+		return errors.Wrap(err, "burning more than it remain")
 	}
-
-	if amount > preBalance {
-		return fmt.Errorf("burning more than it remain")
-	}
-
-	err = ctx.GetStub().PutState(balanceKey, []byte(library.Uint64ToString(preBalance-amount)))
-	if err != nil {
+	if err = subUint256(ctx, value, totalSupplyKey); err != nil {
 		return err
 	}
 
@@ -202,7 +198,7 @@ func (erc20 *ERC20) Burn(ctx context.ContextInterface, msg context.Message, amou
 		Operator: ctx.MsgSender(),
 		From:     fromAddr,
 		To:       library.ZeroAddress,
-		Value:    amount,
+		Value:    value,
 	}); err != nil {
 		return errors.Wrap(err, "Event TransferSingle")
 	}
@@ -214,79 +210,49 @@ func (erc20 *ERC20) Burn(ctx context.ContextInterface, msg context.Message, amou
 
 // Transfer transfers tokens from client account to recipient account.
 // This function triggers a Transfer event.
-func (erc20 *ERC20) Transfer(ctx context.ContextInterface, msg context.Message, to string, amount uint64) error {
-	var err error
-
-	// Nonce Check & Increase
-	if err = erc20.INonce.Check(ctx, ctx.MsgSender().String(), msg.Nonce); err != nil {
+func (erc20 *ERC20) Transfer(ctx context.ContextInterface, msg context.Message, to string, amount string) error {
+	value, err := safemath.ParseUint256(amount)
+	if err != nil {
 		return err
 	}
-	if _, err = erc20.INonce.Increment(ctx, ctx.MsgSender().String()); err != nil {
+	if err = erc20.hooks.Spend(ctx, "", value); err != nil {
 		return err
 	}
-
-	return _transfer(ctx, ctx.MsgSender().String(), to, amount)
-
+	return _transfer(ctx, ctx.MsgSender(), ctx.MsgSender().String(), to, value)
 }
 
-func _transfer(ctx context.ContextInterface, from string, to string, amount uint64) error {
-	var err error
-	toAddr := library.Address(to)
-	fromAddr := library.Address(from)
-
-	if err = toAddr.Validate(); err != nil {
-		return err
-	}
-
-	if err = fromAddr.Validate(); err != nil {
-		return err
-	}
-
-	// beforeTokenTransfer
-
-	senderBalanceKey, err := ctx.GetStub().CreateCompositeKey(BalancePrefix, []string{from})
+// _transfer moves amount from one account to another,and operator is the one moving it in the Transfer event
+func _transfer(ctx context.ContextInterface, operator library.Address, from string, to string, amount safemath.Uint256) error {
+	toAddr, err := library.ParseAddress(to)
 	if err != nil {
 		return err
 	}
-	receiverBalanceKey, err := ctx.GetStub().CreateCompositeKey(BalancePrefix, []string{to})
+	fromAddr, err := library.ParseAddress(from)
 	if err != nil {
 		return err
 	}
 
-	senderBalanceVal, err := ctx.GetStub().GetState(senderBalanceKey)
-	if err != nil {
-		return err
-	}
-	receiverBalanceVal, err := ctx.GetStub().GetState(receiverBalanceKey)
-	if err != nil {
+	if err = toAddr.Validate(); err != nil {
 		return err
 	}
 
-	senderBalance, err := library.BytesToUint64(senderBalanceVal)
-	if err != nil {
-		return err
-	}
-	receiverBalance, err := library.BytesToUint64(receiverBalanceVal)
-	if err != nil {
+	if err = fromAddr.Validate(); err != nil {
 		return err
 	}
 
-	if senderBalance < amount {
-		return fmt.Errorf("transferred more than it has")
-	}
+	// beforeTokenTransfer
 
-	err = ctx.GetStub().PutState(senderBalanceKey, []byte(library.Uint64ToString(amount+receiverBalance)))
-	if err != nil {
-		return err
+	// The receiver's balance is read after the sender's balance is updated,
+	// so transferring to oneself keeps the balance.
+	if err = subUint256(ctx, amount, BalancePrefix, fromAddr.String()); err != nil {
+		return errors.Wrap(err, "transferred more than it has")
 	}
-
-	err = ctx.GetStub().PutState(receiverBalanceKey, []byte(library.Uint64ToString(senderBalance-amount)))
-	if err != nil {
+	if err = addUint256(ctx, amount, BalancePrefix, toAddr.String()); err != nil {
 		return err
 	}
 
 	if err = ctx.EmitEvent("Transfer", &EventTransfer{
-		Operator: ctx.MsgSender(),
+		Operator: operator,
 		From:     fromAddr,
 		To:       toAddr,
 		Value:    amount,
@@ -299,53 +265,81 @@ func _transfer(ctx context.ContextInterface, from string, to string, amount uint
 	return nil
 }
 
-// BalanceOf returns the balance of the given account
-func (erc20 *ERC20) BalanceOf(ctx context.ContextInterface, account string) (uint64, error) {
-	balanceKey, err := ctx.GetStub().CreateCompositeKey(BalancePrefix, []string{account})
+// TransferFromSelf transfers tokens held by the invoked contract's own address(`ctx.Self()`) to the recipient.
+// It is not a transaction but a function for contracts holding tokens,
+// which move them by their own logic without a signature of the holder.
+// This function triggers a Transfer event whose operator is the contract itself.
+func TransferFromSelf(ctx context.ContextInterface, to string, amount safemath.Uint256) error {
+	if err := ctx.Self().Validate(); err != nil {
+		return errors.Wrap(err, "invalid contract address")
+	}
+	return _transfer(ctx, ctx.Self(), ctx.Self().String(), to, amount)
+}
+
+// addUint256 adds delta to the Uint256 value of the key
+func addUint256(ctx context.ContextInterface, delta safemath.Uint256, objectType string, attributes ...string) error {
+	value, err := ctx.State().GetUint256(objectType, attributes...)
 	if err != nil {
-		return 0, errors.Wrap(library.ErrInvalidCompositeKey, err.Error())
+		return err
+	}
+	ok, value := value.TryAdd(delta)
+	if !ok {
+		return safemath.ErrMathOpOverflowed
 	}
-	balance, err := ctx.GetStub().GetState(balanceKey)
+	return ctx.State().PutUint256(value, objectType, attributes...)
+}
+
+// subUint256 subtracts delta from the Uint256 value of the key
+func subUint256(ctx context.ContextInterface, delta safemath.Uint256, objectType string, attributes ...string) error {
+	value, err := ctx.State().GetUint256(objectType, attributes...)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	return library.BytesToUint64(balance)
+	ok, value := value.TrySub(delta)
+	if !ok {
+		return safemath.ErrMathOpOverflowed
+	}
+	return ctx.State().PutUint256(value, objectType, attributes...)
+}
+
+// BalanceOf returns the balance of the given account in decimal
+func (erc20 *ERC20) BalanceOf(ctx context.ContextInterface, account string) (string, error) {
+	accountAddr, err := library.ParseAddress(account)
+	if err != nil {
+		return "", err
+	}
+	balance, err := ctx.State().GetUint256(BalancePrefix, accountAddr.String())
+	if err != nil {
+		return "", err
+	}
+	return balance.String(), nil
 }
 
 // Approve allows the spender to withdraw from the calling client's token account
 // The spender can withdraw multiple times if necessary, up to the value amount
 // This function triggers an Approval event
-func (erc20 *ERC20) Approve(ctx context.ContextInterface, msg context.Message, spender string, amountCap uint64) error {
-	var err error
-	spenderAddr := library.Address(spender)
-
-	if err = spenderAddr.Validate(); err != nil {
-		return err
-	}
-
-	// Nonce Check & Increase
-	if err = erc20.INonce.Check(ctx, ctx.MsgSender().String(), msg.Nonce); err != nil {
+func (erc20 *ERC20) Approve(ctx context.ContextInterface, msg context.Message, spender string, amountCap string) error {
+	spenderAddr, err := library.ParseAddress(spender)
+	if err != nil {
 		return err
 	}
-	if _, err = erc20.INonce.Increment(ctx, ctx.MsgSender().String()); err != nil {
+	if err = spenderAddr.Validate(); err != nil {
 		return err
 	}
-
-	// Might have some check
-
-	approvalKey, err := ctx.GetStub().CreateCompositeKey(ApprovalPrefix, []string{ctx.MsgSender().String(), spender, library.Uint64ToString(amountCap)})
+	capValue, err := safemath.ParseUint256(amountCap)
 	if err != nil {
 		return err
 	}
-	if err = ctx.GetStub().PutState(approvalKey, []byte("Approved")); err != nil {
+
+	// An allowance can be spent by the spender,so it is spent from the session key's spending cap
+	if err = erc20.hooks.Spend(ctx, "", capValue); err != nil {
 		return err
 	}
 
-	allowanceKey, err := ctx.GetStub().CreateCompositeKey(allowancePrefix, []string{approvalKey})
-	if err != nil {
+	if err = ctx.State().PutString(approved, ApprovalPrefix, ctx.MsgSender().String(), spenderAddr.String()); err != nil {
 		return err
 	}
-	if err = ctx.GetStub().PutState(allowanceKey, []byte(library.Uint64ToString(amountCap))); err != nil {
+	if err = ctx.State().PutUint256(capValue, allowancePrefix, ctx.MsgSender().String(), spenderAddr.String()); err != nil {
 		return err
 	}
 
@@ -353,7 +347,7 @@ func (erc20 *ERC20) Approve(ctx context.ContextInterface, msg context.Message, s
 		Owner:     ctx.MsgSender(),
 		Operator:  spenderAddr,
 		Approved:  true,
-		Allowance: amountCap,
+		Allowance: capValue,
 	}); err != nil {
 		return errors.Wrap(err, "Event Approve")
 	}
@@ -363,96 +357,108 @@ func (erc20 *ERC20) Approve(ctx context.ContextInterface, msg context.Message, s
 
 // IsApproved returns if the given owner account approves spender to withdraw from the owner
 func (erc20 *ERC20) IsApproved(ctx context.ContextInterface, owner string, spender string) (bool, error) {
-	approvalKey, err := ctx.GetStub().CreateCompositeKey(ApprovalPrefix, []string{owner, spender})
+	ownerAddr, err := library.ParseAddress(owner)
 	if err != nil {
 		return false, err
 	}
-	approved, err := ctx.GetStub().GetState(approvalKey)
+	spenderAddr, err := library.ParseAddress(spender)
 	if err != nil {
 		return false, err
 	}
 
-	if string(approved) != "Approved" {
-		return false, nil
+	approval, err := ctx.State().GetString(ApprovalPrefix, ownerAddr.String(), spenderAddr.String())
+	if err != nil {
+		return false, err
 	}
 
-	return true, nil
+	return approval == approved, nil
 }
 
-// Allowance returns the amount still available for the spender to withdraw from the owner
-func (erc20 *ERC20) Allowance(ctx context.ContextInterface, owner string, spender string) (uint64, error) {
-	isApproved, err := erc20.IsApproved(ctx, owner, spender)
+// Allowance returns the amount in decimal still available for the spender to withdraw from the owner
+func (erc20 *ERC20) Allowance(ctx context.ContextInterface, owner string, spender string) (string, error) {
+	allowance, err := allowanceOf(ctx, owner, spender)
 	if err != nil {
-		return 0, err
-	}
-	if !isApproved {
-		return 0, fmt.Errorf("not approved")
+		return "", err
 	}
+	return allowance.String(), nil
+}
 
-	approvalKey, err := ctx.GetStub().CreateCompositeKey(ApprovalPrefix, []string{owner, spender})
+func allowanceOf(ctx context.ContextInterface, owner string, spender string) (safemath.Uint256, error) {
+	ownerAddr, err := library.ParseAddress(owner)
 	if err != nil {
-		return 0, err
+		return safemath.Uint256{}, err
+	}
+	spenderAddr, err := library.ParseAddress(spender)
+	if err != nil {
+		return safemath.Uint256{}, err
 	}
 
-	allowanceKey, err := ctx.GetStub().CreateCompositeKey(allowancePrefix, []string{approvalKey})
+	approval, err := ctx.State().GetString(ApprovalPrefix, ownerAddr.String(), spenderAddr.String())
 	if err != nil {
-		return 0, err
+		return safemath.Uint256{}, err
+	}
+	if approval != approved {
+		return safemath.Uint256{}, fmt.Errorf("not approved")
 	}
-	allowance, err := ctx.GetStub().GetState(allowanceKey)
 
-	return library.BytesToUint64(allowance)
+	return ctx.State().GetUint256(allowancePrefix, ownerAddr.String(), spenderAddr.String())
 }
 
 // TransferFrom transfers the value amount from the "from" address to the "to" address
 // This function triggers a Transfer event
-func (erc20 *ERC20) TransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, amount uint64) error {
-
-	var err error
-	// Nonce Check & Increase
-	if err = erc20.INonce.Check(ctx, ctx.MsgSender().String(), msg.Nonce); err != nil {
-		return err
-	}
-	if _, err = erc20.INonce.Increment(ctx, ctx.MsgSender().String()); err != nil {
+func (erc20 *ERC20) TransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, amount string) error {
+	spender := ctx.MsgSender().String()
+	fromAddr, err := library.ParseAddress(from)
+	if err != nil {
 		return err
 	}
-
-	// check if approved
-	isApproved, err := erc20.IsApproved(ctx, from, ctx.MsgSender().String())
+	value, err := safemath.ParseUint256(amount)
 	if err != nil {
 		return err
 	}
-	if !isApproved {
-		return fmt.Errorf("not approved")
-	}
 
 	// check if transfer amount is greater than allowed
-	allowance, err := erc20.Allowance(ctx, from, ctx.MsgSender().String())
+	allowance, err := allowanceOf(ctx, fromAddr.String(), spender)
 	if err != nil {
 		return err
 	}
-	if amount > allowance {
+	ok, remaining := allowance.TrySub(value)
+	if !ok {
 		return fmt.Errorf("transfer amount greater than allowed amount")
 	}
-
-	// make a transfer
-	if err = _transfer(ctx, from, to, amount); err != nil {
-		return err
-	}
-
-	// reduce allowance by reclaiming approval
-	approvalKey, err := ctx.GetStub().CreateCompositeKey(ApprovalPrefix, []string{from, to})
-	if err != nil {
+	if err = erc20.hooks.Spend(ctx, "", value); err != nil {
 		return err
 	}
 
-	allowanceKey, err := ctx.GetStub().CreateCompositeKey(allowancePrefix, []string{approvalKey})
-	if err != nil {
+	// make a transfer
+	if err = _transfer(ctx, ctx.MsgSender(), fromAddr.String(), to, value); err != nil {
 		return err
 	}
 
-	if err = ctx.GetStub().PutState(allowanceKey, []byte(library.Uint64ToString(allowance-amount))); err != nil {
-		return err
-	}
+	// reduce allowance of the spender
+	return ctx.State().PutUint256(remaining, allowancePrefix, fromAddr.String(), spender)
+}
 
-	return nil
+// MigrateAddresses moves the balances,approvals and allowances kept under mixed case addresses,
+// which were stored before addresses were canonical,to the keys of their canonical addresses.
+// - balances of the same account are summed,so the supply is kept
+// - an approval or allowance kept under the canonical addresses is newer than the moved one,so it is kept
+// Anyone can run it as it never moves tokens between accounts. It returns the number of keys moved,which is 0 once migrated
+func (erc20 *ERC20) MigrateAddresses(ctx context.ContextInterface) (uint64, error) {
+	var count uint64
+	for _, migration := range []struct {
+		objectType string
+		merge      func(moved []byte, current []byte) ([]byte, error)
+	}{
+		{BalancePrefix, state.SumUint256},
+		{ApprovalPrefix, state.KeepCurrent},
+		{allowancePrefix, state.KeepCurrent},
+	} {
+		moved, err := ctx.State().MigrateKeys(migration.objectType, library.CanonicalAttributes, migration.merge)
+		if err != nil {
+			return count, errors.Wrapf(err, "migrate %s", migration.objectType)
+		}
+		count += moved
+	}
+	return count, nil
 }
@@ -0,0 +1,207 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package erc20
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/contracts/nonce"
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/context/contexttest"
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/stretchr/testify/assert"
+)
+
+// assertBalance asserts the balance of account in decimal
+func assertBalance(t *testing.T, cc *contexttest.Chaincode, account *contexttest.Account, expected string) {
+	balance, err := cc.Invoke(nil, "BalanceOf", account.String())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, balance)
+}
+
+func TestERC20(t *testing.T) {
+	erc20 := NewERC20(nonce.NewNonceContract())
+	cc := contexttest.NewChaincode(t, "erc20", erc20)
+	alice, bob, carol := contexttest.NewAccount(t), contexttest.NewAccount(t), contexttest.NewAccount(t)
+
+	t.Run("Mint and Burn keep the total supply", func(t *testing.T) {
+		assert.NoError(t, cc.Submit(alice, "Mint", alice.String(), "100"))
+		assert.NoError(t, cc.Submit(alice, "Burn", "10"))
+		assertBalance(t, cc, alice, "90")
+
+		supply, err := cc.Invoke(nil, "TotalSupply")
+		assert.NoError(t, err)
+		assert.Equal(t, "90", supply)
+	})
+
+	t.Run("Transfer moves tokens from the sender to the recipient", func(t *testing.T) {
+		assert.NoError(t, cc.Submit(alice, "Transfer", bob.String(), "30"))
+		assertBalance(t, cc, alice, "60")
+		assertBalance(t, cc, bob, "30")
+
+		// transferring to oneself keeps the balance
+		assert.NoError(t, cc.Submit(alice, "Transfer", alice.String(), "60"))
+		assertBalance(t, cc, alice, "60")
+
+		assert.Error(t, cc.Submit(alice, "Transfer", bob.String(), "61"))
+		assertBalance(t, cc, alice, "60")
+	})
+
+	t.Run("Approve and TransferFrom spend the allowance of the spender", func(t *testing.T) {
+		assert.NoError(t, cc.Submit(alice, "Approve", bob.String(), "50"))
+
+		// the allowance is approved by owner and spender regardless of its amount
+		approved, err := cc.Invoke(nil, "IsApproved", alice.String(), bob.String())
+		assert.NoError(t, err)
+		assert.Equal(t, true, approved)
+
+		assert.NoError(t, cc.Submit(bob, "TransferFrom", alice.String(), carol.String(), "20"))
+		assertBalance(t, cc, alice, "40")
+		assertBalance(t, cc, carol, "20")
+
+		// the allowance of the spender is reduced rather than the one of the recipient
+		allowance, err := cc.Invoke(nil, "Allowance", alice.String(), bob.String())
+		assert.NoError(t, err)
+		assert.Equal(t, "30", allowance)
+		assert.Error(t, cc.Submit(bob, "TransferFrom", alice.String(), carol.String(), "31"))
+
+		// allowances are kept under the objectType `allowance`
+		allowanceKey, err := cc.Stub.CreateCompositeKey("allowance", []string{alice.String(), bob.String()})
+		assert.NoError(t, err)
+		val, err := cc.Stub.GetState(allowanceKey)
+		assert.NoError(t, err)
+		assert.NotNil(t, val)
+	})
+
+	t.Run("Signed transactions use the nonces of their senders", func(t *testing.T) {
+		// the failed transfer is discarded with its nonce
+		nonce, err := cc.Invoke(nil, "Current", alice.String())
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(5), nonce)
+	})
+}
+
+func TestTransferFromSelf(t *testing.T) {
+	cc := contexttest.NewChaincode(t, "erc20", NewERC20(nonce.NewNonceContract()))
+	alice, bob := contexttest.NewAccount(t), contexttest.NewAccount(t)
+
+	// transferFromSelf transfers amount from the contract's own address in a transaction of alice
+	var self library.Address
+	transferFromSelf := func(to string, amount uint64) error {
+		return cc.Run(alice, "", false, nil, func(ctx *context.Context, _ *context.Message) error {
+			self = ctx.Self()
+			return TransferFromSelf(ctx, to, math.NewUint256(amount))
+		})
+	}
+	// assertSelfBalance asserts the balance of the contract's own address
+	assertSelfBalance := func(expected string) {
+		balance, err := cc.Invoke(nil, "BalanceOf", self.String())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, balance)
+	}
+
+	// the contract holds the tokens minted to its own address
+	assert.NoError(t, transferFromSelf(bob.String(), 0))
+	assert.NoError(t, cc.Submit(alice, "Mint", self.String(), "100"))
+
+	t.Run("Moves the tokens of the contract", func(t *testing.T) {
+		assert.NoError(t, transferFromSelf(bob.String(), 30))
+		events := cc.Events()
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, "Transfer", events[0].Name)
+			assert.JSONEq(t, `{"operator":"`+self.String()+`","from":"`+self.String()+`","to":"`+bob.String()+`","value":"30"}`, string(events[0].Payload))
+		}
+		assertSelfBalance("70")
+		assertBalance(t, cc, bob, "30")
+	})
+
+	t.Run("Rejects the zero address", func(t *testing.T) {
+		assert.ErrorIs(t, transferFromSelf(library.ZeroAddress.String(), 1), library.ErrInvalidAddressNull)
+		assertSelfBalance("70")
+	})
+
+	t.Run("Rejects transfers beyond the balance", func(t *testing.T) {
+		assert.ErrorIs(t, transferFromSelf(bob.String(), 71), math.ErrMathOpOverflowed)
+		assertSelfBalance("70")
+		assertBalance(t, cc, bob, "30")
+
+		assert.NoError(t, transferFromSelf(bob.String(), 70))
+		assertSelfBalance("0")
+		assertBalance(t, cc, bob, "100")
+	})
+}
+
+func TestMigrateAddresses(t *testing.T) {
+	erc20 := NewERC20(nonce.NewNonceContract())
+	cc := contexttest.NewChaincode(t, "erc20", erc20)
+	alice, bob := contexttest.NewAccount(t), contexttest.NewAccount(t)
+	upper := func(account *contexttest.Account) string {
+		return library.AddressPrefix + strings.ToUpper(account.String()[2:])
+	}
+
+	assert.NoError(t, cc.Submit(alice, "Mint", alice.String(), "100"))
+	assert.NoError(t, cc.Submit(alice, "Approve", bob.String(), "5"))
+
+	// keys stored before addresses were canonical
+	legacy := []struct {
+		key   []string
+		value string
+	}{
+		{[]string{BalancePrefix, alice.Address.Checksum()}, "40"},
+		{[]string{BalancePrefix, upper(alice)}, "2"},
+		{[]string{BalancePrefix, bob.Address.Checksum()}, "7"},
+		{[]string{allowancePrefix, alice.Address.Checksum(), bob.Address.Checksum()}, "30"},
+	}
+	cc.Stub.MockTransactionStart("legacy")
+	for _, entry := range legacy {
+		key, err := cc.Stub.CreateCompositeKey(entry.key[0], entry.key[1:])
+		assert.NoError(t, err)
+		assert.NoError(t, cc.Stub.PutState(key, []byte(entry.value)))
+	}
+	cc.Stub.MockTransactionEnd("legacy")
+
+	t.Run("Moves the legacy keys to the canonical ones", func(t *testing.T) {
+		count, err := cc.Invoke(alice, "MigrateAddresses")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(4), count)
+
+		// balances of the same account are summed
+		assertBalance(t, cc, alice, "142")
+		assertBalance(t, cc, bob, "7")
+		// the allowance approved under the canonical address is newer
+		allowance, err := cc.Invoke(nil, "Allowance", alice.String(), bob.String())
+		assert.NoError(t, err)
+		assert.Equal(t, "5", allowance)
+
+		for _, entry := range legacy {
+			key, err := cc.Stub.CreateCompositeKey(entry.key[0], entry.key[1:])
+			assert.NoError(t, err)
+			value, err := cc.Stub.GetState(key)
+			assert.NoError(t, err)
+			assert.Nil(t, value)
+		}
+	})
+
+	t.Run("Runs again without moving anything", func(t *testing.T) {
+		count, err := cc.Invoke(bob, "MigrateAddresses")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), count)
+		assertBalance(t, cc, alice, "142")
+	})
+}
@@ -19,41 +19,46 @@ package erc20
 import (
 	"github.com/bestchains/bestchains-contracts/library"
 	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/math"
 )
 
 type EventTransfer struct {
 	Operator library.Address `json:"operator"`
 	From     library.Address `json:"from"`
 	To       library.Address `json:"to"`
-	Value    uint64          `json:"value"`
+	Value    math.Uint256    `json:"value"`
 }
 
 type EventApproval struct {
 	Owner     library.Address `json:"owner"`
 	Operator  library.Address `json:"operator"`
 	Approved  bool            `json:"approved"`
-	Allowance uint64          `json:"allowance"`
+	Allowance math.Uint256    `json:"allowance"`
 }
 
 type IERC20 interface {
+	MessageVersion(ctx context.ContextInterface) (uint8, error)
+
 	Name(ctx context.ContextInterface) (string, error)
 	Symbol(ctx context.ContextInterface) (string, error)
 	Decimal(ctx context.ContextInterface) (uint8, error) // Same as ETH
 
-	Mint(ctx context.ContextInterface, msg context.Message, to string, amount uint64) error
-	Burn(ctx context.ContextInterface, msg context.Message, amount uint64) error
+	Mint(ctx context.ContextInterface, msg context.Message, to string, amount string) error
+	Burn(ctx context.ContextInterface, msg context.Message, amount string) error
 
-	BalanceOf(ctx context.ContextInterface, account string) (uint64, error)
+	BalanceOf(ctx context.ContextInterface, account string) (string, error)
 
-	Approve(ctx context.ContextInterface, msg context.Message, spender string, amountCap uint64) error
+	Approve(ctx context.ContextInterface, msg context.Message, spender string, amountCap string) error
 	IsApproved(ctx context.ContextInterface, owner string, spender string) (bool, error)
 
-	Allowance(ctx context.ContextInterface, ownerAccount string, spenderAccount string) (uint64, error)
+	Allowance(ctx context.ContextInterface, ownerAccount string, spenderAccount string) (string, error)
+
+	Transfer(ctx context.ContextInterface, msg context.Message, to string, amount string) error
+	TransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, amount string) error
 
-	Transfer(ctx context.ContextInterface, msg context.Message, to string, amount uint64) error
-	TransferFrom(ctx context.ContextInterface, msg context.Message, from string, to string, amount uint64) error
+	MigrateAddresses(ctx context.ContextInterface) (uint64, error)
 }
 
 type ISupply interface {
-	TotalSupply(ctx context.ContextInterface) (uint64, error)
+	TotalSupply(ctx context.ContextInterface) (string, error)
 }
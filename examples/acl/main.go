@@ -18,11 +18,27 @@ package main
 
 import (
 	"github.com/bestchains/bestchains-contracts/contracts/access"
+	"github.com/bestchains/bestchains-contracts/contracts/nonce"
+	"github.com/bestchains/bestchains-contracts/library/initializable"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
+// The initializer of the contract is restricted at build time,
+// like `-ldflags "-X main.initializerHash=<hash> -X main.initializerMSPID=<MSP ID>"`.
+// Otherwise whoever initializes the contract first becomes its owner.
+var (
+	// initializerHash is the pre-committed `initializable.InitializerHash` of the initializer's address
+	initializerHash string
+	// initializerMSPID is the MSP ID of the organization the initializer belongs to
+	initializerMSPID string
+)
+
 func main() {
-	aclContract := access.NewAccessControlContract(access.NewOwnableContract())
+	nonceContract := nonce.NewNonceContract()
+	aclContract := access.NewAccessControlContract(
+		nonceContract,
+		access.NewOwnableContract(nonceContract, initializable.PoliciesFromBuild(initializerHash, initializerMSPID)),
+	)
 	cc, err := contractapi.NewChaincode(aclContract)
 	if err != nil {
 		panic(err.Error())
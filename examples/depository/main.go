@@ -20,14 +20,27 @@ import (
 	"github.com/bestchains/bestchains-contracts/contracts/access"
 	"github.com/bestchains/bestchains-contracts/contracts/depository"
 	"github.com/bestchains/bestchains-contracts/contracts/nonce"
+	"github.com/bestchains/bestchains-contracts/library/initializable"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
+// The initializer of the contract is restricted at build time,
+// like `-ldflags "-X main.initializerHash=<hash> -X main.initializerMSPID=<MSP ID>"`.
+// Otherwise whoever initializes the contract first becomes its owner.
+var (
+	// initializerHash is the pre-committed `initializable.InitializerHash` of the initializer's address
+	initializerHash string
+	// initializerMSPID is the MSP ID of the organization the initializer belongs to
+	initializerMSPID string
+)
+
 func main() {
+	nonceContract := nonce.NewNonceContract()
 	depositoryContract := depository.NewDepositoryContract(
-		nonce.NewNonceContract(),
+		nonceContract,
 		access.NewAccessControlContract(
-			access.NewOwnableContract(),
+			nonceContract,
+			access.NewOwnableContract(nonceContract, initializable.PoliciesFromBuild(initializerHash, initializerMSPID)),
 		),
 	)
 	cc, err := contractapi.NewChaincode(depositoryContract)
@@ -32,6 +32,7 @@ limitations under the License.
 package main
 
 import (
+	"github.com/bestchains/bestchains-contracts/contracts/delegation"
 	"github.com/bestchains/bestchains-contracts/contracts/nonce"
 	"github.com/bestchains/bestchains-contracts/contracts/token/erc20"
 	"github.com/bestchains/bestchains-contracts/library/context"
@@ -39,17 +40,13 @@ import (
 )
 
 func main() {
-	nonceContract := new(nonce.Nonce)
-	nonceContract.Name = "org.bestchains.com.NonceContract"
-	nonceContract.TransactionContextHandler = new(context.Context)
-	nonceContract.BeforeTransaction = context.BeforeTransaction
+	nonceContract := nonce.NewNonceContract()
 
-	erc20Contract := erc20.NewERC20(nonceContract)
-	erc20Contract.Contract.Name = "org.bestchains.com.ERC20Contract"
-	erc20Contract.TransactionContextHandler = new(context.Context)
-	erc20Contract.BeforeTransaction = context.BeforeTransaction
+	// Accept messages signed by session keys which are delegated in the delegation contract
+	erc20Contract := erc20.NewERC20(nonceContract, context.WithDelegationStore(context.LedgerDelegationStore{}))
+	delegationContract := delegation.NewDelegationContract(nonceContract)
 
-	cc, err := contractapi.NewChaincode(erc20Contract)
+	cc, err := contractapi.NewChaincode(erc20Contract, delegationContract)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -18,15 +18,11 @@ package main
 
 import (
 	"github.com/bestchains/bestchains-contracts/contracts/nonce"
-	"github.com/bestchains/bestchains-contracts/library/context"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
 func main() {
-	nonceContract := new(nonce.Nonce)
-	nonceContract.Name = "org.bestchains.com.NonceContract"
-	nonceContract.TransactionContextHandler = new(context.Context)
-	nonceContract.BeforeTransaction = context.BeforeTransaction
+	nonceContract := nonce.NewNonceContract().(*nonce.Nonce)
 
 	cc, err := contractapi.NewChaincode(nonceContract)
 	if err != nil {
@@ -18,15 +18,11 @@ package main
 
 import (
 	"github.com/bestchains/bestchains-contracts/contracts/timelock"
-	"github.com/bestchains/bestchains-contracts/library/context"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
 func main() {
-	timeLockContract := new(timelock.TimeLock)
-	timeLockContract.Name = "org.bestchains.com.IimeLockContract"
-	timeLockContract.TransactionContextHandler = new(context.Context)
-	timeLockContract.BeforeTransaction = context.BeforeTransaction
+	timeLockContract := timelock.NewTimeLockContract("org.bestchains.com.IimeLockContract")
 
 	cc, err := contractapi.NewChaincode(timeLockContract)
 	if err != nil {
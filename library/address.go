@@ -18,9 +18,12 @@ package library
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
+	"encoding/binary"
 	"encoding/hex"
 	"regexp"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -32,6 +35,7 @@ var (
 	ErrInvalidAddressMissingPrefix = errors.New("missing prefix 0x")
 	ErrInvalidAddressBadCharacters = errors.New("contains invalid characters")
 	ErrInvalidAddressNull          = errors.New("null address")
+	ErrInvalidAddressChecksum      = errors.New("invalid address checksum")
 	ErrUnknownAddressAlg           = errors.New("unknown crypto algorithm for address")
 )
 
@@ -55,13 +59,21 @@ func (addr *Address) FromString(addrStr string) {
 	*addr = Address(addrStr)
 }
 
+// FromPublicKey derives the address from an ECDSA(any curve) or Ed25519 public key
 func (addr *Address) FromPublicKey(pub interface{}) error {
-	publicKey, ok := pub.(*ecdsa.PublicKey)
-	if !ok {
+	var serializedPubKey []byte
+
+	// Serialize the public key
+	switch publicKey := pub.(type) {
+	case *ecdsa.PublicKey:
+		serializedPubKey = elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y)
+	case ed25519.PublicKey:
+		serializedPubKey = publicKey
+	case *ed25519.PublicKey:
+		serializedPubKey = *publicKey
+	default:
 		return ErrUnknownAddressAlg
 	}
-	// Serialize the public key
-	serializedPubKey := elliptic.Marshal(elliptic.P256(), publicKey.X, publicKey.Y)
 
 	// Hash the public key using Keccak-256
 	hashedPubKey := sha3.Sum256(serializedPubKey)
@@ -72,6 +84,85 @@ func (addr *Address) FromPublicKey(pub interface{}) error {
 	return nil
 }
 
+// contractAddressPrefix prevents a contract address from being the address of any public key,
+// just like the 0xff prefix of CREATE2
+const contractAddressPrefix = 0xff
+
+// ContractAddress derives the deterministic address of a contract from where it is deployed,
+// which is the same on every peer and never changes with upgrades.
+// A contract can have more than one address with different salts,while salt can be empty.
+func ContractAddress(channelID string, chaincode string, contract string, salt string) Address {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write([]byte{contractAddressPrefix})
+	for _, part := range []string{channelID, chaincode, contract, salt} {
+		// Length prefixed so the parts can not be shifted into each other
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(part)))
+		hasher.Write(length[:])
+		hasher.Write([]byte(part))
+	}
+	hashed := hasher.Sum(nil)
+
+	return Address(AddressPrefix + hex.EncodeToString(hashed[12:]))
+}
+
+// ParseAddress parses a hex address and returns its canonical(lower case) form,
+// which is the form used in state keys and comparisons.
+// A mixed case address must have a valid EIP-55 checksum,while an all lower or upper case one has no checksum.
+func ParseAddress(addrStr string) (Address, error) {
+	addr := Address(addrStr)
+	if err := addr.validateFormat(); err != nil {
+		return "", err
+	}
+	return addr.Canonical(), nil
+}
+
+// Canonical returns the lower case form of the address
+func (addr Address) Canonical() Address {
+	return Address(strings.ToLower(string(addr)))
+}
+
+// CanonicalAttributes returns the attributes of a composite key with the addresses in canonical form,
+// which is the key of the same account stored before addresses were canonical.
+// An attribute is taken as an address if it is 42 characters prefixed by `0x`,regardless of its checksum.
+func CanonicalAttributes(attributes []string) []string {
+	canonical := make([]string, len(attributes))
+	for i, attribute := range attributes {
+		if len(attribute) == 42 && strings.HasPrefix(attribute, AddressPrefix) {
+			attribute = Address(attribute).Canonical().String()
+		}
+		canonical[i] = attribute
+	}
+	return canonical
+}
+
+// Equal tells whether two addresses are the same account regardless of their cases
+func (addr Address) Equal(other Address) bool {
+	return strings.EqualFold(string(addr), string(other))
+}
+
+// Checksum returns the EIP-55 mixed case encoding of the address
+func (addr Address) Checksum() string {
+	lower := strings.ToLower(strings.TrimPrefix(string(addr), AddressPrefix))
+
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write([]byte(lower))
+	hashed := hasher.Sum(nil)
+
+	checksummed := []byte(lower)
+	for i, c := range checksummed {
+		// Upper case a letter if the corresponding nibble of the hash is 8 or greater
+		nibble := hashed[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if c >= 'a' && c <= 'f' && nibble&0x0f >= 8 {
+			checksummed[i] = c - 'a' + 'A'
+		}
+	}
+	return AddressPrefix + string(checksummed)
+}
+
 func (addr Address) EmptyAddress() bool {
 	if addr == ZeroAddress || addr == "" || addr == AddressPrefix {
 		return true
@@ -80,6 +171,20 @@ func (addr Address) EmptyAddress() bool {
 }
 
 func (addr Address) Validate() error {
+	if err := addr.validateFormat(); err != nil {
+		return err
+	}
+
+	// Check null address
+	if addr.Canonical() == ZeroAddress {
+		return ErrInvalidAddressNull
+	}
+
+	return nil
+}
+
+// validateFormat checks the address is a hex address with a valid checksum if it is mixed case
+func (addr Address) validateFormat() error {
 	// Check length
 	if len(addr) != 42 {
 		return ErrInvalidAddressLength
@@ -96,9 +201,10 @@ func (addr Address) Validate() error {
 		return ErrInvalidAddressBadCharacters
 	}
 
-	// Check null address
-	if addr == ZeroAddress {
-		return ErrInvalidAddressNull
+	// Check EIP-55 checksum of mixed case address
+	hexPart := string(addr[2:])
+	if hexPart != strings.ToLower(hexPart) && hexPart != strings.ToUpper(hexPart) && addr.Checksum() != addr.String() {
+		return ErrInvalidAddressChecksum
 	}
 
 	return nil
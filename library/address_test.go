@@ -18,6 +18,9 @@ package library
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
 	"os"
@@ -47,6 +50,21 @@ func TestAddressGenerate(t *testing.T) {
 	addr := new(Address)
 	assert.Nil(t, addr.FromPublicKey(&ecdsaPrivKey.PublicKey))
 	assert.Equal(t, TestECDSAAddress, addr.String())
+
+	p384PrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(t, err)
+	assert.Nil(t, addr.FromPublicKey(&p384PrivKey.PublicKey))
+	assert.Nil(t, addr.Validate())
+
+	ed25519PubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	assert.Nil(t, addr.FromPublicKey(ed25519PubKey))
+	assert.Nil(t, addr.Validate())
+	ed25519Addr := *addr
+	assert.Nil(t, addr.FromPublicKey(&ed25519PubKey))
+	assert.Equal(t, ed25519Addr, *addr)
+
+	assert.Equal(t, ErrUnknownAddressAlg, addr.FromPublicKey("not a key"))
 }
 
 func TestAddressValidate(t *testing.T) {
@@ -55,3 +73,55 @@ func TestAddressValidate(t *testing.T) {
 	assert.Equal(t, ErrInvalidAddressBadCharacters, TestInvalidAddress3.Validate())
 	assert.Equal(t, ErrInvalidAddressNull, TestInvalidAddress4.Validate())
 }
+
+func TestAddressChecksum(t *testing.T) {
+	// test vectors of EIP-55
+	for _, checksummed := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		addr := Address(checksummed)
+		assert.Equal(t, checksummed, addr.Canonical().Checksum())
+		assert.Nil(t, addr.Validate())
+
+		parsed, err := ParseAddress(checksummed)
+		assert.Nil(t, err)
+		assert.Equal(t, addr.Canonical(), parsed)
+		assert.True(t, parsed.Equal(addr))
+	}
+
+	// all lower or upper case addresses have no checksum
+	parsed, err := ParseAddress("0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED")
+	assert.Nil(t, err)
+	assert.Equal(t, Address("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"), parsed)
+
+	// mixed case address with a wrong checksum
+	_, err = ParseAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD")
+	assert.Equal(t, ErrInvalidAddressChecksum, err)
+	assert.Equal(t, ErrInvalidAddressChecksum, Address("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD").Validate())
+
+	// null address can be parsed but is not valid
+	parsed, err = ParseAddress(ZeroAddress.String())
+	assert.Nil(t, err)
+	assert.Equal(t, ErrInvalidAddressNull, parsed.Validate())
+
+	// attributes of legacy keys are canonical regardless of their checksums,while other attributes are kept
+	assert.Equal(t,
+		[]string{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "1", "0xAB"},
+		CanonicalAttributes([]string{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", "1", "0xAB"}),
+	)
+}
+
+func TestContractAddress(t *testing.T) {
+	addr := ContractAddress("channel", "erc20", "org.bestchains.com.ERC20Contract", "")
+	assert.Nil(t, addr.Validate())
+	assert.Equal(t, addr, addr.Canonical())
+	assert.Equal(t, addr, ContractAddress("channel", "erc20", "org.bestchains.com.ERC20Contract", ""))
+
+	assert.NotEqual(t, addr, ContractAddress("channel", "erc20", "org.bestchains.com.ERC20Contract", "escrow"))
+	assert.NotEqual(t, addr, ContractAddress("channel2", "erc20", "org.bestchains.com.ERC20Contract", ""))
+	// parts are length prefixed
+	assert.NotEqual(t, ContractAddress("ab", "c", "", ""), ContractAddress("a", "bc", "", ""))
+}
@@ -20,6 +20,9 @@ import (
 	"encoding/json"
 
 	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/bestchains/bestchains-contracts/library/state"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
@@ -29,17 +32,37 @@ var (
 	ErrEmptyEventName      = errors.New("empty event name")
 	ErrNilEventPayload     = errors.New("nil event payload")
 	ErrInvalidEventPayload = errors.New("invalid event payload")
+
+	ErrNoClientIdentity          = errors.New("no client identity")
+	ErrOperatorAttributeMismatch = errors.New("operator attribute mismatch")
 )
 
+// EnrollmentIDAttribute is the certificate attribute Fabric CA sets to the enrollment ID of the identity
+const EnrollmentIDAttribute = "hf.EnrollmentID"
+
 type ContextInterface interface {
 	contractapi.TransactionContextInterface
 
 	Operator() library.Address
+	OperatorMSPID() (string, error)
+	OperatorAttribute(name string) (string, bool, error)
+	AssertOperatorAttribute(name, value string) error
 
 	SetMsgSender(library.Address)
 	MsgSender() library.Address
+	SetMsgSigner(library.Address)
+	MsgSigner() library.Address
+
+	SetContract(name string, salt string)
+	Self() library.Address
+
+	SetIdentityPolicy(policy IdentityPolicy)
+	Caller() (library.Address, error)
 
 	EmitEvent(event string, payload interface{}) error
+	FlushEvents() error
+
+	State() *state.State
 }
 
 type Context struct {
@@ -50,30 +73,41 @@ type Context struct {
 
 	// msgSender who is responsible the payload
 	msgSender library.Address
-}
 
-func BeforeTransaction(ctx ContextInterface) error {
-	var err error
+	// msgSigner who signs the payload,which is a session key of msgSender if the payload is delegated
+	msgSigner library.Address
 
-	_, args := ctx.GetStub().GetFunctionAndParameters()
-	if len(args) > 1 {
-		msg := new(Message)
+	// contractName and contractSalt of the invoked contract which derive its address
+	contractName string
+	contractSalt string
 
-		// DO NOT HAVE
-		if err = msg.Unmarshal([]byte(args[0])); err != nil {
-			return nil
-		}
+	// self is the address of the invoked contract
+	self library.Address
 
-		// Validate Args
-		msgSender, err := msg.VerifyAgainstArgs(args[1:]...)
-		if err != nil {
-			return err
-		}
+	// identityPolicy of the invoked contract which decides who the caller is
+	identityPolicy IdentityPolicy
+
+	// events emitted by this tx which are flushed by `FlushEvents`
+	events []protocol.Event
+
+	// state caches the world state of this tx which is flushed by `AfterTransaction`
+	state *state.State
+}
 
-		// set msg sender
-		ctx.SetMsgSender(msgSender)
+// SetStub sets the stub of this tx and drops the cached state and events of the previous stub
+func (ctx *Context) SetStub(stub shim.ChaincodeStubInterface) {
+	ctx.TransactionContext.SetStub(stub)
+	ctx.state = nil
+	ctx.events = nil
+	ctx.self = ""
+}
+
+// State returns the cached world state of this tx
+func (ctx *Context) State() *state.State {
+	if ctx.state == nil {
+		ctx.state = state.New(ctx.GetStub())
 	}
-	return nil
+	return ctx.state
 }
 
 func (ctx *Context) Operator() library.Address {
@@ -94,6 +128,41 @@ func (ctx *Context) Operator() library.Address {
 	return ctx.operator
 }
 
+// OperatorMSPID returns the MSP ID of the organisation the operator belongs to
+func (ctx *Context) OperatorMSPID() (string, error) {
+	clientIdentity := ctx.GetClientIdentity()
+	if clientIdentity == nil {
+		return "", ErrNoClientIdentity
+	}
+	return clientIdentity.GetMSPID()
+}
+
+// OperatorAttribute returns the value of the attribute in the operator's certificate
+// and whether the attribute is found. The enrollment ID is found by `EnrollmentIDAttribute`.
+func (ctx *Context) OperatorAttribute(name string) (string, bool, error) {
+	clientIdentity := ctx.GetClientIdentity()
+	if clientIdentity == nil {
+		return "", false, ErrNoClientIdentity
+	}
+	return clientIdentity.GetAttributeValue(name)
+}
+
+// AssertOperatorAttribute returns ErrOperatorAttributeMismatch
+// if the attribute in the operator's certificate is not found or not equal to value
+func (ctx *Context) AssertOperatorAttribute(name, value string) error {
+	val, found, err := ctx.OperatorAttribute(name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.Wrapf(ErrOperatorAttributeMismatch, "attribute %s not found", name)
+	}
+	if val != value {
+		return errors.Wrapf(ErrOperatorAttributeMismatch, "attribute %s is %s, not %s", name, val, value)
+	}
+	return nil
+}
+
 func (ctx *Context) SetMsgSender(msgSender library.Address) {
 	ctx.msgSender = msgSender
 }
@@ -102,6 +171,51 @@ func (ctx *Context) MsgSender() library.Address {
 	return ctx.msgSender
 }
 
+func (ctx *Context) SetMsgSigner(msgSigner library.Address) {
+	ctx.msgSigner = msgSigner
+}
+
+// MsgSigner returns who signs the message,which is the message sender unless the message is delegated
+func (ctx *Context) MsgSigner() library.Address {
+	return ctx.msgSigner
+}
+
+// SetContract sets the name and salt of the invoked contract which derive the address returned by `Self`
+func (ctx *Context) SetContract(name string, salt string) {
+	ctx.contractName = name
+	ctx.contractSalt = salt
+	ctx.self = ""
+}
+
+// Self returns the deterministic address of the invoked contract by `library.ContractAddress`,
+// which can hold assets just like any other address.
+// It is ZeroAddress if the contract is unknown.
+func (ctx *Context) Self() library.Address {
+	if ctx.self == "" {
+		if ctx.contractName == "" {
+			return library.ZeroAddress
+		}
+		chaincode, err := chaincodeName(ctx.GetStub())
+		if err != nil {
+			return library.ZeroAddress
+		}
+		ctx.self = library.ContractAddress(ctx.GetStub().GetChannelID(), chaincode, ctx.contractName, ctx.contractSalt)
+	}
+	return ctx.self
+}
+
+// SetIdentityPolicy sets the identity policy of the invoked contract which decides the caller returned by `Caller`
+func (ctx *Context) SetIdentityPolicy(policy IdentityPolicy) {
+	ctx.identityPolicy = policy
+}
+
+// Caller returns who calls this tx by the identity policy of the invoked contract,
+// which ownership and role checks are against
+func (ctx *Context) Caller() (library.Address, error) {
+	return ctx.identityPolicy.Identify(ctx)
+}
+
+// EmitEvent buffers the event which is flushed with other events of this tx by `FlushEvents`
 func (ctx *Context) EmitEvent(event string, payload interface{}) error {
 	if event == "" {
 		return ErrEmptyEventName
@@ -115,5 +229,37 @@ func (ctx *Context) EmitEvent(event string, payload interface{}) error {
 		return errors.Wrap(ErrInvalidEventPayload, err.Error())
 	}
 
-	return ctx.GetStub().SetEvent(event, bytes)
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return err
+	}
+
+	ctx.events = append(ctx.events, protocol.Event{
+		Name:      event,
+		Payload:   bytes,
+		TxID:      ctx.GetStub().GetTxID(),
+		Timestamp: txTimestamp.GetSeconds(),
+	})
+	return nil
+}
+
+// FlushEvents sets the buffered events as one EventEnvelope
+func (ctx *Context) FlushEvents() error {
+	if len(ctx.events) == 0 {
+		return nil
+	}
+
+	bytes, err := json.Marshal(protocol.EventEnvelope{
+		Version: protocol.EventEnvelopeVersion,
+		Events:  ctx.events,
+	})
+	if err != nil {
+		return err
+	}
+	if err = ctx.GetStub().SetEvent(protocol.EventEnvelopeName, bytes); err != nil {
+		return err
+	}
+
+	ctx.events = nil
+	return nil
 }
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeforeTransaction(t *testing.T) {
+	hooks := context.NewHooks()
+	hooks.Register(new(testContract))
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	var sender = new(library.Address)
+	assert.NoError(t, sender.FromPublicKey(&privateKey.PublicKey))
+
+	// signedCall signs args against domain and returns the stub arguments
+	signedCall := func(domain protocol.Domain, args ...string) []string {
+		msg := &context.Message{Nonce: 0}
+		assert.NoError(t, msg.GenerateSignature(domain, privateKey, args...))
+		bytes, err := msg.Marshal()
+		assert.NoError(t, err)
+		return append([]string{string(bytes)}, args...)
+	}
+
+	t.Run("Signed call", func(t *testing.T) {
+		args := signedCall(protocol.NewDomain("channel", "erc20", "Transfer"), "to", "1")
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "Transfer", args...))
+
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+		assert.Equal(t, *sender, ctx.MsgSender())
+	})
+
+	t.Run("Signed call replayed on another chaincode", func(t *testing.T) {
+		args := signedCall(protocol.NewDomain("channel", "erc20", "Transfer"), "to", "1")
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "depository", "Transfer", args...))
+
+		assert.ErrorIs(t, hooks.BeforeTransaction(ctx), protocol.ErrInvalidMessage)
+	})
+
+	t.Run("Signed call to an unsigned function", func(t *testing.T) {
+		args := signedCall(protocol.NewDomain("channel", "erc20", "BalanceOf"), "to", "1")
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "BalanceOf", args...))
+
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+		assert.Equal(t, library.Address(""), ctx.MsgSender())
+	})
+}
+
+// newTestCreator returns a serialized identity of mspID whose certificate carries Fabric CA attributes
+func newTestCreator(t *testing.T, mspID string, attrs string) []byte {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "alice"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{
+			// Fabric CA attributes extension
+			Id:    asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1},
+			Value: []byte(attrs),
+		}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	assert.NoError(t, err)
+
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	assert.NoError(t, err)
+	return creator
+}
+
+func TestOperatorIdentity(t *testing.T) {
+	stub := shimtest.NewMockStub("depository", nil)
+	stub.Creator = newTestCreator(t, "Org1MSP", `{"attrs":{"hf.EnrollmentID":"alice","role":"auditor"}}`)
+
+	clientIdentity, err := cid.New(stub)
+	assert.NoError(t, err)
+
+	ctx := new(context.Context)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(clientIdentity)
+
+	mspID, err := ctx.OperatorMSPID()
+	assert.NoError(t, err)
+	assert.Equal(t, "Org1MSP", mspID)
+
+	enrollmentID, found, err := ctx.OperatorAttribute(context.EnrollmentIDAttribute)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "alice", enrollmentID)
+
+	_, found, err = ctx.OperatorAttribute("department")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, ctx.AssertOperatorAttribute("role", "auditor"))
+	assert.ErrorIs(t, ctx.AssertOperatorAttribute("role", "admin"), context.ErrOperatorAttributeMismatch)
+	assert.ErrorIs(t, ctx.AssertOperatorAttribute("department", "finance"), context.ErrOperatorAttributeMismatch)
+
+	t.Run("No client identity", func(t *testing.T) {
+		ctx := new(context.Context)
+		ctx.SetStub(stub)
+
+		_, err := ctx.OperatorMSPID()
+		assert.ErrorIs(t, err, context.ErrNoClientIdentity)
+		assert.ErrorIs(t, ctx.AssertOperatorAttribute("role", "auditor"), context.ErrNoClientIdentity)
+	})
+}
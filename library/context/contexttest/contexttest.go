@@ -0,0 +1,457 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package contexttest invokes contracts in tests the way peers do:
+// every call is a transaction whose message is signed for real and verified by the hooks of the contract,
+// and whose cached state and events are flushed after it succeeds.
+package contexttest
+
+import (
+	"container/list"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ChannelID is the channel which the chaincodes are invoked on
+	ChannelID = "channel"
+	// MSPID is the MSP which the accounts submitting transactions belong to
+	MSPID = "Org1MSP"
+)
+
+var (
+	messageType = reflect.TypeOf(context.Message{})
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Account is a key which signs messages and submits transactions
+type Account struct {
+	Address library.Address
+
+	key     *ecdsa.PrivateKey
+	creator []byte
+
+	// delegator is who delegates the account as a session key,empty if the account signs for itself
+	delegator library.Address
+}
+
+// NewAccount generates an account
+func NewAccount(t testing.TB) *Account {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := &Account{key: key}
+	if err = account.Address.FromPublicKey(&key.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// the account submits transactions with a certificate of its own key,so it is the operator of them as well
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: account.Address.String()},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.creator, err = proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   MSPID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+// SessionKey generates a session key which signs messages on behalf of the account
+func (account *Account) SessionKey(t testing.TB) *Account {
+	t.Helper()
+	sessionKey := NewAccount(t)
+	sessionKey.delegator = account.Address
+	return sessionKey
+}
+
+// Sender returns the sender of the messages signed by the account
+func (account *Account) Sender() library.Address {
+	if account.delegator != "" {
+		return account.delegator
+	}
+	return account.Address
+}
+
+// String returns the address of the account
+func (account *Account) String() string {
+	return account.Address.String()
+}
+
+// Chaincode invokes the contracts of a chaincode on a MockStub
+type Chaincode struct {
+	t testing.TB
+
+	// Stub keeps the world state of the chaincode
+	Stub *shimtest.MockStub
+	// Now is the timestamp of the next transactions in unix seconds,the current time if it is 0
+	Now int64
+
+	contracts []contractapi.ContractInterface
+	txs       int
+	events    []protocol.Event
+}
+
+// NewChaincode creates a chaincode named name of contracts,the first of which is the default contract
+func NewChaincode(t testing.TB, name string, contracts ...contractapi.ContractInterface) *Chaincode {
+	stub := shimtest.NewMockStub(name, nil)
+	stub.ChannelID = ChannelID
+	return &Chaincode{t: t, Stub: stub, contracts: contracts}
+}
+
+// Events returns the events emitted by the last transaction which succeeds
+func (cc *Chaincode) Events() []protocol.Event {
+	return cc.events
+}
+
+// Submit invokes function with args in a transaction submitted by from.
+// See Invoke.
+func (cc *Chaincode) Submit(from *Account, function string, args ...string) error {
+	_, err := cc.Invoke(from, function, args...)
+	return err
+}
+
+// Invoke invokes function with args in a transaction submitted by from and returns the result of the function.
+// The function is `contract:function` or a function of the default contract.
+// A function which takes a Message is called with a message signed by from with its current nonce,
+// and the args are converted to the parameter types of the function as contractapi does.
+func (cc *Chaincode) Invoke(from *Account, function string, args ...string) (interface{}, error) {
+	cc.t.Helper()
+	contract := cc.contract(function)
+	_, name := protocol.SplitFunction(function)
+	method := reflect.ValueOf(contract).MethodByName(name)
+	if !method.IsValid() {
+		cc.t.Fatalf("%s has no function %s", contract.GetName(), name)
+	}
+	methodType := method.Type()
+	signed := methodType.NumIn() > 1 && methodType.In(1) == messageType
+
+	var result interface{}
+	err := cc.Run(from, function, signed, args, func(ctx *context.Context, msg *context.Message) error {
+		in := []reflect.Value{reflect.ValueOf(ctx)}
+		if signed {
+			in = append(in, reflect.ValueOf(*msg))
+		}
+		if len(in)+len(args) != methodType.NumIn() {
+			cc.t.Fatalf("%s takes %d args but got %d", name, methodType.NumIn()-len(in), len(args))
+		}
+		for _, arg := range args {
+			in = append(in, cc.parseArg(arg, methodType.In(len(in))))
+		}
+
+		out := method.Call(in)
+		last := out[len(out)-1]
+		if last.Type() == errorType && !last.IsNil() {
+			return last.Interface().(error)
+		}
+		if len(out) > 1 {
+			result = out[0].Interface()
+		}
+		return nil
+	})
+	return result, err
+}
+
+// parseArg converts arg to typ,strings as they are and others from JSON
+func (cc *Chaincode) parseArg(arg string, typ reflect.Type) reflect.Value {
+	cc.t.Helper()
+	value := reflect.New(typ).Elem()
+	if typ.Kind() == reflect.String {
+		value.SetString(arg)
+		return value
+	}
+	if err := json.Unmarshal([]byte(arg), value.Addr().Interface()); err != nil {
+		cc.t.Fatalf("invalid %s arg %s: %s", typ, arg, err)
+	}
+	return value
+}
+
+// Run runs fn as function in a transaction submitted by from between the hooks of the contract,
+// which is signed by from if signed. A nil from submits the transaction without a creator.
+// The transaction is discarded if it fails,just as peers never commit it.
+func (cc *Chaincode) Run(from *Account, function string, signed bool, args []string, fn func(ctx *context.Context, msg *context.Message) error) error {
+	cc.t.Helper()
+	contract := cc.contract(function)
+	if _, name := protocol.SplitFunction(function); name != "" {
+		function = contract.GetName() + ":" + name
+	}
+
+	stub := &Stub{MockStub: cc.Stub, function: function, args: args}
+	msg := new(context.Message)
+	if signed {
+		msg = cc.sign(stub, from, function, args)
+		bytes, err := msg.Marshal()
+		if err != nil {
+			cc.t.Fatal(err)
+		}
+		stub.args = append([]string{string(bytes)}, args...)
+	}
+
+	cc.txs++
+	txID := "tx" + strconv.Itoa(cc.txs)
+	cc.Stub.MockTransactionStart(txID)
+	defer cc.Stub.MockTransactionEnd(txID)
+	if cc.Now != 0 {
+		cc.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: cc.Now}
+	}
+	cc.Stub.Creator = nil
+	if from != nil {
+		cc.Stub.Creator = from.creator
+	}
+	proposal, err := proposalOf(cc.Stub.Name)
+	if err != nil {
+		cc.t.Fatal(err)
+	}
+	stub.proposal = proposal
+
+	ctx := new(context.Context)
+	ctx.SetStub(stub)
+	if from != nil {
+		clientIdentity, err := cid.New(stub)
+		if err != nil {
+			cc.t.Fatal(err)
+		}
+		ctx.SetClientIdentity(clientIdentity)
+	}
+
+	state, keys := cc.snapshot()
+	if err = cc.transact(contract, ctx, msg, fn); err != nil {
+		cc.Stub.State = state
+		cc.Stub.Keys = keys
+		cc.drainEvents()
+		return err
+	}
+	cc.events = cc.drainEvents()
+	return nil
+}
+
+// transact runs fn between the hooks of the contract
+func (cc *Chaincode) transact(contract contractapi.ContractInterface, ctx *context.Context, msg *context.Message, fn func(ctx *context.Context, msg *context.Message) error) error {
+	if before, ok := contract.GetBeforeTransaction().(func(context.ContextInterface) error); ok {
+		if err := before(ctx); err != nil {
+			return err
+		}
+	}
+	if err := fn(ctx, msg); err != nil {
+		return err
+	}
+	if after, ok := contract.GetAfterTransaction().(func(context.ContextInterface) error); ok {
+		if err := after(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contract returns the contract of function
+func (cc *Chaincode) contract(function string) contractapi.ContractInterface {
+	cc.t.Helper()
+	if len(cc.contracts) == 0 {
+		cc.t.Fatal("no contracts")
+	}
+	name, _ := protocol.SplitFunction(function)
+	if name == "" {
+		return cc.contracts[0]
+	}
+	for _, contract := range cc.contracts {
+		if contract.GetName() == name {
+			return contract
+		}
+	}
+	cc.t.Fatalf("no contract %s", name)
+	return nil
+}
+
+// sign signs the call of function with args by from with the current nonce of its sender
+func (cc *Chaincode) sign(stub shim.ChaincodeStubInterface, from *Account, function string, args []string) *context.Message {
+	cc.t.Helper()
+	if from == nil {
+		cc.t.Fatalf("%s must be signed", function)
+	}
+	ctx := new(context.Context)
+	ctx.SetStub(stub)
+	nonce, err := context.LedgerNonceStore{}.Current(ctx, from.Sender().String())
+	if err != nil {
+		cc.t.Fatal(err)
+	}
+
+	msg := &context.Message{
+		Nonce:     nonce,
+		Version:   protocol.LatestMessageVersion,
+		Delegator: from.delegator.String(),
+	}
+	if err = msg.GenerateSignature(protocol.NewDomain(ChannelID, cc.Stub.Name, function), from.key, args...); err != nil {
+		cc.t.Fatal(err)
+	}
+	return msg
+}
+
+// snapshot copies the world state,so a failed transaction can be discarded
+func (cc *Chaincode) snapshot() (map[string][]byte, *list.List) {
+	state := make(map[string][]byte, len(cc.Stub.State))
+	keys := make([]string, 0, len(cc.Stub.State))
+	for key, value := range cc.Stub.State {
+		state[key] = value
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	ordered := list.New()
+	for _, key := range keys {
+		ordered.PushBack(key)
+	}
+	return state, ordered
+}
+
+// drainEvents returns the events set by the transaction,so the channel of MockStub never fills up
+func (cc *Chaincode) drainEvents() []protocol.Event {
+	cc.t.Helper()
+	var events []protocol.Event
+	for len(cc.Stub.ChaincodeEventsChannel) > 0 {
+		event := <-cc.Stub.ChaincodeEventsChannel
+		decoded, err := protocol.DecodeEvents(event.EventName, event.Payload)
+		if err != nil {
+			cc.t.Fatal(err)
+		}
+		events = append(events, decoded...)
+	}
+	return events
+}
+
+// proposalOf returns a signed proposal which invokes chaincode
+func proposalOf(chaincode string) (*peer.SignedProposal, error) {
+	input, err := proto.Marshal(&peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: chaincode},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := proto.Marshal(&peer.ChaincodeProposalPayload{Input: input})
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := proto.Marshal(&peer.Proposal{Payload: payload})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal proposal")
+	}
+	return &peer.SignedProposal{ProposalBytes: proposal}, nil
+}
+
+// Stub is a MockStub invoked with a function and a signed proposal,
+// which serves paged queries as MockStub does not support them
+type Stub struct {
+	*shimtest.MockStub
+
+	function string
+	args     []string
+	proposal *peer.SignedProposal
+}
+
+func (stub *Stub) GetFunctionAndParameters() (string, []string) {
+	return stub.function, stub.args
+}
+
+func (stub *Stub) GetSignedProposal() (*peer.SignedProposal, error) {
+	return stub.proposal, nil
+}
+
+// GetStateByPartialCompositeKeyWithPagination returns pageSize keys from bookmark,
+// and the bookmark is the key the next page starts from,which is empty after the last page
+func (stub *Stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	iterator, err := stub.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iterator.Close()
+
+	page := &pageIterator{}
+	metadata := &peer.QueryResponseMetadata{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if kv.GetKey() < bookmark {
+			continue
+		}
+		if int32(len(page.kvs)) == pageSize {
+			metadata.Bookmark = kv.GetKey()
+			break
+		}
+		page.kvs = append(page.kvs, kv)
+	}
+	metadata.FetchedRecordsCount = int32(len(page.kvs))
+	return page, metadata, nil
+}
+
+// pageIterator iterates over a page of keys
+type pageIterator struct {
+	kvs []*queryresult.KV
+}
+
+func (page *pageIterator) HasNext() bool {
+	return len(page.kvs) > 0
+}
+
+func (page *pageIterator) Next() (*queryresult.KV, error) {
+	if len(page.kvs) == 0 {
+		return nil, errors.New("no more keys")
+	}
+	kv := page.kvs[0]
+	page.kvs = page.kvs[1:]
+	return kv, nil
+}
+
+func (page *pageIterator) Close() error {
+	return nil
+}
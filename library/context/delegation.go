@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DelegationPrefix is the composite key prefix of the delegations kept by LedgerDelegationStore
+	DelegationPrefix = "delegation~delegator~delegate"
+)
+
+var (
+	// ErrDelegationNotSupported is returned when a delegated message is sent to hooks without a DelegationStore.
+	ErrDelegationNotSupported = errors.New("delegation not supported")
+	// ErrDelegationNotFound is returned when the signer is not delegated by the delegator.
+	ErrDelegationNotFound = errors.New("delegation not found")
+	// ErrDelegationExpired is returned when the delegation is expired at the transaction timestamp.
+	ErrDelegationExpired = errors.New("delegation expired")
+	// ErrFunctionNotDelegated is returned when the delegate calls a function which is not delegated.
+	ErrFunctionNotDelegated = errors.New("function not delegated")
+	// ErrSpendingCapExceeded is returned when the delegate spends more than its spending cap.
+	ErrSpendingCapExceeded = errors.New("spending cap exceeded")
+)
+
+// DelegationStore keeps the delegations from delegators to their session keys.
+// `BeforeTransaction` reads the delegation of every delegated message,so a revoked delegation is rejected immediately.
+type DelegationStore interface {
+	// GetDelegation returns the delegation from delegator to delegate,nil if there is none
+	GetDelegation(ctx ContextInterface, delegator string, delegate string) (*protocol.Delegation, error)
+	// PutDelegation creates or updates a delegation
+	PutDelegation(ctx ContextInterface, delegation *protocol.Delegation) error
+	// DeleteDelegation deletes the delegation from delegator to delegate
+	DeleteDelegation(ctx ContextInterface, delegator string, delegate string) error
+}
+
+// LedgerDelegationStore keeps delegations in the world state under `DelegationPrefix`,
+// which is the same state `DelegationContract` uses.
+type LedgerDelegationStore struct{}
+
+var _ DelegationStore = LedgerDelegationStore{}
+
+func (LedgerDelegationStore) GetDelegation(ctx ContextInterface, delegator string, delegate string) (*protocol.Delegation, error) {
+	delegation := new(protocol.Delegation)
+	found, err := ctx.State().GetJSON(delegation, DelegationPrefix, delegator, delegate)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	return delegation, nil
+}
+
+func (LedgerDelegationStore) PutDelegation(ctx ContextInterface, delegation *protocol.Delegation) error {
+	return ctx.State().PutJSON(delegation, DelegationPrefix, delegation.Delegator, delegation.Delegate)
+}
+
+func (LedgerDelegationStore) DeleteDelegation(ctx ContextInterface, delegator string, delegate string) error {
+	key, err := ctx.State().Key(DelegationPrefix, delegator, delegate)
+	if err != nil {
+		return err
+	}
+	return ctx.State().Delete(key)
+}
+
+// SpendingAsset returns the key of asset in the contract at address,
+// which the spending of a delegation is kept under
+func SpendingAsset(contract library.Address, asset string) string {
+	if asset == "" {
+		return contract.String()
+	}
+	return contract.String() + "/" + asset
+}
+
+// useDelegation checks that the signer is delegated by the delegator to call function at the transaction timestamp
+func useDelegation(ctx ContextInterface, store DelegationStore, delegator string, signer string, function string) error {
+	if store == nil {
+		return ErrDelegationNotSupported
+	}
+	delegation, err := store.GetDelegation(ctx, delegator, signer)
+	if err != nil {
+		return err
+	}
+	if delegation == nil {
+		return errors.Wrapf(ErrDelegationNotFound, "%s is not delegated by %s", signer, delegator)
+	}
+
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return err
+	}
+	if delegation.Expired(txTimestamp.GetSeconds()) {
+		return errors.Wrapf(ErrDelegationExpired, "expired at %d", delegation.ExpiresAt)
+	}
+	if !delegation.Allows(function) {
+		return errors.Wrap(ErrFunctionNotDelegated, function)
+	}
+	return nil
+}
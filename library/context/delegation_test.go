@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelegation(t *testing.T) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	var delegator = new(library.Address)
+	assert.NoError(t, delegator.FromPublicKey(&rootKey.PublicKey))
+
+	sessionKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	var delegate = new(library.Address)
+	assert.NoError(t, delegate.FromPublicKey(&sessionKey.PublicKey))
+
+	domain := protocol.NewDomain("channel", "erc20", "Transfer")
+
+	// delegatedArgs signs a message with the session key on behalf of the delegator
+	delegatedArgs := func(nonce uint64) []string {
+		msg := &context.Message{Nonce: nonce, Delegator: delegator.String()}
+		assert.NoError(t, msg.GenerateSignature(domain, sessionKey, "to", "1"))
+		bytes, err := msg.Marshal()
+		assert.NoError(t, err)
+		return []string{string(bytes), "to", "1"}
+	}
+
+	// newCtx returns a context calling `Transfer` where the session key is delegated with functions
+	newCtx := func(functions []string, spendingCap uint64, expiresAt int64) (*context.Context, *testStub) {
+		stub := newTestStub(t, "erc20", "Transfer", delegatedArgs(0)...)
+		ctx := new(context.Context)
+		ctx.SetStub(stub)
+		assert.NoError(t, context.LedgerDelegationStore{}.PutDelegation(ctx, &protocol.Delegation{
+			Delegator:   delegator.String(),
+			Delegate:    delegate.String(),
+			Functions:   functions,
+			SpendingCap: math.NewUint256(spendingCap),
+			ExpiresAt:   expiresAt,
+		}))
+		return ctx, stub
+	}
+	hooks := newTestHooks(context.WithDelegationStore(context.LedgerDelegationStore{}))
+	hooks.CapSpending()
+
+	t.Run("Delegated call", func(t *testing.T) {
+		ctx, stub := newCtx([]string{"Transfer"}, 10, 1<<40)
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+		assert.Equal(t, *delegator, ctx.MsgSender())
+		assert.Equal(t, *delegate, ctx.MsgSigner())
+
+		// the nonce of the delegator is used
+		curr, err := context.LedgerNonceStore{}.Current(ctx, delegator.String())
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), curr)
+
+		// spend from the spending cap
+		assert.NoError(t, hooks.Spend(ctx, "", math.NewUint256(6)))
+		assert.ErrorIs(t, hooks.Spend(ctx, "", math.NewUint256(5)), context.ErrSpendingCapExceeded)
+		assert.NoError(t, hooks.Spend(ctx, "", math.NewUint256(4)))
+		assert.ErrorIs(t, hooks.Spend(ctx, "", math.MaxUint256), context.ErrSpendingCapExceeded)
+
+		// the spending cap is spent per asset
+		assert.NoError(t, hooks.Spend(ctx, "1", math.NewUint256(10)))
+		assert.ErrorIs(t, hooks.Spend(ctx, "1", math.NewUint256(1)), context.ErrSpendingCapExceeded)
+		delegation, err := context.LedgerDelegationStore{}.GetDelegation(ctx, delegator.String(), delegate.String())
+		assert.NoError(t, err)
+		assert.Equal(t, math.NewUint256(10), delegation.Spent(context.SpendingAsset(ctx.Self(), "")))
+		assert.Equal(t, math.NewUint256(10), delegation.Spent(context.SpendingAsset(ctx.Self(), "1")))
+
+		// revocation takes effect immediately
+		assert.NoError(t, context.LedgerDelegationStore{}.DeleteDelegation(ctx, delegator.String(), delegate.String()))
+		stub.args = delegatedArgs(1)
+		assert.ErrorIs(t, hooks.BeforeTransaction(ctx), context.ErrDelegationNotFound)
+		assert.Equal(t, library.Address(""), ctx.MsgSender())
+	})
+
+	t.Run("Spending cap beyond uint64", func(t *testing.T) {
+		ctx, _ := newCtx([]string{"Transfer"}, 0, 1<<40)
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+		spendingCap, err := math.ParseUint256("100000000000000000000000")
+		assert.NoError(t, err)
+		delegation, err := context.LedgerDelegationStore{}.GetDelegation(ctx, delegator.String(), delegate.String())
+		assert.NoError(t, err)
+		delegation.SpendingCap = spendingCap
+		assert.NoError(t, context.LedgerDelegationStore{}.PutDelegation(ctx, delegation))
+
+		amount, err := math.ParseUint256("60000000000000000000000")
+		assert.NoError(t, err)
+		assert.NoError(t, hooks.Spend(ctx, "", amount))
+		assert.ErrorIs(t, hooks.Spend(ctx, "", amount), context.ErrSpendingCapExceeded)
+
+		delegation, err = context.LedgerDelegationStore{}.GetDelegation(ctx, delegator.String(), delegate.String())
+		assert.NoError(t, err)
+		assert.Equal(t, "40000000000000000000000", delegation.Remaining(context.SpendingAsset(ctx.Self(), "")).String())
+	})
+
+	t.Run("Function not delegated", func(t *testing.T) {
+		ctx, _ := newCtx([]string{"org.bestchains.com.ERC20Contract:Transfer", "Approve"}, 10, 1<<40)
+		assert.ErrorIs(t, hooks.BeforeTransaction(ctx), context.ErrFunctionNotDelegated)
+	})
+
+	t.Run("Expired delegation", func(t *testing.T) {
+		ctx, _ := newCtx([]string{"Transfer"}, 10, 1)
+		assert.ErrorIs(t, hooks.BeforeTransaction(ctx), context.ErrDelegationExpired)
+	})
+
+	t.Run("Tampered delegator", func(t *testing.T) {
+		ctx, stub := newCtx([]string{"Transfer"}, 10, 1<<40)
+		msg := new(context.Message)
+		assert.NoError(t, msg.Unmarshal([]byte(stub.args[0])))
+		msg.Delegator = delegate.String()
+		bytes, err := msg.Marshal()
+		assert.NoError(t, err)
+		stub.args[0] = string(bytes)
+		assert.ErrorIs(t, hooks.BeforeTransaction(ctx), protocol.ErrInvalidMessage)
+	})
+
+	t.Run("Delegation not supported", func(t *testing.T) {
+		ctx, _ := newCtx([]string{"Transfer"}, 10, 1<<40)
+		assert.ErrorIs(t, newTestHooks().BeforeTransaction(ctx), context.ErrDelegationNotSupported)
+
+		// contracts which do not cap spending never accept session keys
+		uncapped := newTestHooks(context.WithDelegationStore(context.LedgerDelegationStore{}))
+		assert.ErrorIs(t, uncapped.BeforeTransaction(ctx), context.ErrDelegationNotSupported)
+	})
+
+	t.Run("Spend without delegation", func(t *testing.T) {
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "Transfer"))
+		ctx.SetMsgSender(*delegator)
+		ctx.SetMsgSigner(*delegator)
+		assert.NoError(t, hooks.Spend(ctx, "", math.MaxUint256))
+	})
+}
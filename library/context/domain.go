@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+// DomainFromStub builds the Domain of the current transaction to contract from the stub.
+// The contract is the registered name of the invoked contract instead of the namespace in the stub,
+// which is empty when the default contract is invoked.
+func DomainFromStub(stub shim.ChaincodeStubInterface, contract string) (protocol.Domain, error) {
+	chaincode, err := chaincodeName(stub)
+	if err != nil {
+		return protocol.Domain{}, errors.Wrap(protocol.ErrInvalidDomain, err.Error())
+	}
+	function, _ := stub.GetFunctionAndParameters()
+	domain := protocol.NewDomain(stub.GetChannelID(), chaincode, function)
+	domain.Contract = contract
+	return domain, nil
+}
+
+// chaincodeName extracts the invoked chaincode's name from the signed proposal
+func chaincodeName(stub shim.ChaincodeStubInterface) (string, error) {
+	signedProposal, err := stub.GetSignedProposal()
+	if err != nil {
+		return "", err
+	}
+	if signedProposal == nil {
+		return "", errors.New("nil signed proposal")
+	}
+
+	proposal := new(peer.Proposal)
+	if err = proto.Unmarshal(signedProposal.GetProposalBytes(), proposal); err != nil {
+		return "", errors.Wrap(err, "unmarshal proposal")
+	}
+	payload := new(peer.ChaincodeProposalPayload)
+	if err = proto.Unmarshal(proposal.GetPayload(), payload); err != nil {
+		return "", errors.Wrap(err, "unmarshal proposal payload")
+	}
+	spec := new(peer.ChaincodeInvocationSpec)
+	if err = proto.Unmarshal(payload.GetInput(), spec); err != nil {
+		return "", errors.Wrap(err, "unmarshal chaincode invocation spec")
+	}
+
+	name := spec.GetChaincodeSpec().GetChaincodeId().GetName()
+	if name == "" {
+		return "", errors.New("empty chaincode name")
+	}
+	return name, nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context_test
+
+import (
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+// testStub wraps MockStub with a fixed function call and signed proposal
+type testStub struct {
+	*shimtest.MockStub
+
+	function string
+	args     []string
+	proposal *peer.SignedProposal
+}
+
+func newTestStub(t *testing.T, chaincode string, function string, args ...string) *testStub {
+	input, err := proto.Marshal(&peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: chaincode},
+		},
+	})
+	assert.NoError(t, err)
+	payload, err := proto.Marshal(&peer.ChaincodeProposalPayload{Input: input})
+	assert.NoError(t, err)
+	proposal, err := proto.Marshal(&peer.Proposal{Payload: payload})
+	assert.NoError(t, err)
+
+	stub := &testStub{
+		MockStub: shimtest.NewMockStub(chaincode, nil),
+		function: function,
+		args:     args,
+		proposal: &peer.SignedProposal{ProposalBytes: proposal},
+	}
+	stub.ChannelID = "channel"
+	stub.MockTransactionStart("tx")
+	return stub
+}
+
+func (stub *testStub) GetFunctionAndParameters() (string, []string) {
+	return stub.function, stub.args
+}
+
+func (stub *testStub) GetSignedProposal() (*peer.SignedProposal, error) {
+	return stub.proposal, nil
+}
+
+func TestDomain(t *testing.T) {
+	t.Run("DomainFromStub", func(t *testing.T) {
+		stub := newTestStub(t, "erc20", "erc20:transfer")
+		domain, err := context.DomainFromStub(stub, "erc20")
+		assert.NoError(t, err)
+		assert.Equal(t, protocol.NewDomain("channel", "erc20", "erc20:Transfer"), domain)
+		assert.Equal(t, protocol.SchemeVersion, domain.Version)
+	})
+
+	t.Run("DomainFromStub of the default contract", func(t *testing.T) {
+		stub := newTestStub(t, "erc20", "transfer")
+		domain, err := context.DomainFromStub(stub, "erc20")
+		assert.NoError(t, err)
+		assert.Equal(t, protocol.NewDomain("channel", "erc20", "erc20:Transfer"), domain)
+	})
+
+	t.Run("DomainFromStub without proposal", func(t *testing.T) {
+		stub := newTestStub(t, "erc20", "Transfer")
+		stub.proposal = &peer.SignedProposal{}
+		_, err := context.DomainFromStub(stub, "erc20")
+		assert.ErrorIs(t, err, protocol.ErrInvalidDomain)
+	})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context_test
+
+import (
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents(t *testing.T) {
+	t.Run("EmitEvent and AfterTransaction", func(t *testing.T) {
+		stub := newTestStub(t, "acl", "Initialize")
+		ctx := new(context.Context)
+		ctx.SetStub(stub)
+
+		assert.ErrorIs(t, ctx.EmitEvent("", "payload"), context.ErrEmptyEventName)
+		assert.ErrorIs(t, ctx.EmitEvent("OwnershipTransferred", nil), context.ErrNilEventPayload)
+		assert.NoError(t, ctx.EmitEvent("OwnershipTransferred", map[string]string{"newOwner": "0x1"}))
+		assert.NoError(t, ctx.EmitEvent("RoleGranted", map[string]string{"account": "0x2"}))
+		assert.Len(t, stub.ChaincodeEventsChannel, 0)
+
+		assert.NoError(t, context.NewHooks().AfterTransaction(ctx))
+		assert.Len(t, stub.ChaincodeEventsChannel, 1)
+		event := <-stub.ChaincodeEventsChannel
+		assert.Equal(t, protocol.EventEnvelopeName, event.EventName)
+
+		events, err := protocol.DecodeEvents(event.EventName, event.Payload)
+		assert.NoError(t, err)
+		assert.Len(t, events, 2)
+		txTimestamp, err := stub.GetTxTimestamp()
+		assert.NoError(t, err)
+		for index, name := range []string{"OwnershipTransferred", "RoleGranted"} {
+			assert.Equal(t, name, events[index].Name)
+			assert.Equal(t, "tx", events[index].TxID)
+			assert.Equal(t, txTimestamp.GetSeconds(), events[index].Timestamp)
+		}
+		assert.JSONEq(t, `{"account":"0x2"}`, string(events[1].Payload))
+
+		// nothing left to flush
+		assert.NoError(t, context.NewHooks().AfterTransaction(ctx))
+		assert.Len(t, stub.ChaincodeEventsChannel, 0)
+	})
+
+	t.Run("AfterTransaction flushes state", func(t *testing.T) {
+		stub := newTestStub(t, "erc20", "Transfer")
+		ctx := new(context.Context)
+		ctx.SetStub(stub)
+
+		assert.NoError(t, ctx.State().PutUint64(1, "totalSupply"))
+		value, _ := stub.GetState("totalSupply")
+		assert.Nil(t, value)
+
+		assert.NoError(t, context.NewHooks().AfterTransaction(ctx))
+		value, _ = stub.GetState("totalSupply")
+		assert.Equal(t, []byte("1"), value)
+
+		// a new stub drops the cached state
+		ctx.SetStub(newTestStub(t, "erc20", "Transfer"))
+		supply, err := ctx.State().GetUint64("totalSupply")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), supply)
+	})
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"reflect"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrMissingMessage is returned when a function which takes a Message is called without a Message.
+	ErrMissingMessage = errors.New("missing message")
+)
+
+var (
+	messageType          = reflect.TypeOf(Message{})
+	contextInterfaceType = reflect.TypeOf((*ContextInterface)(nil)).Elem()
+)
+
+// Hooks holds the per-contract configuration of the transaction hooks.
+// A contract uses its hooks by `contract.BeforeTransaction = hooks.BeforeTransaction`
+// and `contract.AfterTransaction = hooks.AfterTransaction`
+type Hooks struct {
+	// acceptLegacy keeps accepting MessageV1 signatures during the migration window
+	acceptLegacy bool
+
+	// signedFunctions are the registered functions which take a Message
+	signedFunctions map[string]bool
+
+	// nonceStore protects signed calls from being replayed
+	nonceStore NonceStore
+
+	// delegationStore resolves messages signed by session keys,nil if delegation is not supported
+	delegationStore DelegationStore
+
+	// spendingCapped tells whether the contract spends the spending caps of session keys
+	spendingCapped bool
+
+	// contractName is the name of the registered contract
+	contractName string
+
+	// contractSalt derives the address of the contract with its name
+	contractSalt string
+
+	// identityPolicy decides who the caller is in ownership and role checks
+	identityPolicy IdentityPolicy
+}
+
+// HookOption configures Hooks
+type HookOption func(hooks *Hooks)
+
+// WithLegacyMessages keeps accepting legacy MessageV1 signatures.
+// It is meant for the migration window only and should be removed once all clients sign with MessageV2.
+func WithLegacyMessages() HookOption {
+	return func(hooks *Hooks) {
+		hooks.acceptLegacy = true
+	}
+}
+
+// WithNonceStore keeps the nonces of message senders in store instead of LedgerNonceStore.
+// Replay protection of signed calls is done by the hooks with the store,so contracts never use nonces by themselves.
+func WithNonceStore(store NonceStore) HookOption {
+	return func(hooks *Hooks) {
+		hooks.nonceStore = store
+	}
+}
+
+// WithDelegationStore accepts messages signed by session keys which are delegated in store,
+// but only in contracts which declare `CapSpending`.
+func WithDelegationStore(store DelegationStore) HookOption {
+	return func(hooks *Hooks) {
+		hooks.delegationStore = store
+	}
+}
+
+// WithContractSalt derives the address of the contract with salt,
+// so the same contract deployed in the same chaincode can have different addresses
+func WithContractSalt(salt string) HookOption {
+	return func(hooks *Hooks) {
+		hooks.contractSalt = salt
+	}
+}
+
+// WithIdentityPolicy decides who the caller is in ownership and role checks,
+// which is the sender of a signed call and the operator of an unsigned call by default
+func WithIdentityPolicy(policy IdentityPolicy) HookOption {
+	return func(hooks *Hooks) {
+		hooks.identityPolicy = policy
+	}
+}
+
+// NewHooks creates Hooks with the given options
+func NewHooks(opts ...HookOption) *Hooks {
+	hooks := &Hooks{
+		nonceStore: LedgerNonceStore{},
+	}
+	for _, opt := range opts {
+		opt(hooks)
+	}
+	return hooks
+}
+
+// Register registers the functions of the contract which take a Message,
+// whose first parameter after the transaction context is `context.Message`.
+// Only registered functions get a message sender in BeforeTransaction.
+// The name of the contract is used to derive its address,so it should be set before registering.
+func (hooks *Hooks) Register(contract interface{}) {
+	if hooks.signedFunctions == nil {
+		hooks.signedFunctions = make(map[string]bool)
+	}
+	if named, ok := contract.(interface{ GetName() string }); ok {
+		hooks.contractName = named.GetName()
+	}
+	contractType := reflect.TypeOf(contract)
+	for i := 0; i < contractType.NumMethod(); i++ {
+		method := contractType.Method(i)
+		if takesMessage(method.Type) {
+			hooks.signedFunctions[method.Name] = true
+		}
+	}
+}
+
+// CapSpending declares that the contract calls `Spend` before moving the sender's assets.
+// Messages signed by session keys are rejected by contracts which do not declare it,
+// as nothing would stop a session key from moving the sender's assets beyond its spending cap.
+func (hooks *Hooks) CapSpending() {
+	hooks.spendingCapped = true
+}
+
+// IdentityPolicy returns the policy which identifies the caller in ownership and role checks
+func (hooks *Hooks) IdentityPolicy() IdentityPolicy {
+	return hooks.identityPolicy
+}
+
+// IsSigned tells whether the function takes a Message
+func (hooks *Hooks) IsSigned(function string) bool {
+	_, fn := protocol.SplitFunction(function)
+	return hooks.signedFunctions[fn]
+}
+
+// takesMessage tells whether the first parameter after the transaction context is a Message.
+// The receiver is the parameter 0 of a method type.
+func takesMessage(methodType reflect.Type) bool {
+	if methodType.NumIn() < 3 {
+		return false
+	}
+	if !methodType.In(1).Implements(contextInterfaceType) {
+		return false
+	}
+	return methodType.In(2) == messageType
+}
+
+// RequiredMessageVersion returns the lowest message version accepted by these hooks
+func (hooks *Hooks) RequiredMessageVersion() protocol.MessageVersion {
+	if hooks.acceptLegacy {
+		return protocol.MessageV1
+	}
+	return protocol.LatestMessageVersion
+}
+
+// MessageVersion tells clients which message version they should sign with.
+// Contracts which accept messages expose it as a transaction.
+func (hooks *Hooks) MessageVersion(ctx ContextInterface) (uint8, error) {
+	return uint8(hooks.RequiredMessageVersion()), nil
+}
+
+// BeforeTransaction clears the message sender left in the context and verifies the message of registered functions.
+// A registered function must be called with a valid message at `args[0]`,which is verified against the rest args,
+// then the nonce of the message is used and the message sender is set. Other functions never get a message sender.
+// A message with `Delegator` set is sent by the delegator if its signer is delegated to call the function.
+func (hooks *Hooks) BeforeTransaction(ctx ContextInterface) error {
+	// Clear any leftover sender
+	ctx.SetMsgSender(library.Address(""))
+	ctx.SetMsgSigner(library.Address(""))
+	ctx.SetContract(hooks.contractName, hooks.contractSalt)
+	ctx.SetIdentityPolicy(hooks.identityPolicy)
+
+	function, args := ctx.GetStub().GetFunctionAndParameters()
+	if !hooks.IsSigned(function) {
+		return nil
+	}
+
+	if len(args) == 0 {
+		return ErrMissingMessage
+	}
+	msg := new(Message)
+	if err := msg.Unmarshal([]byte(args[0])); err != nil {
+		return errors.Wrap(ErrMissingMessage, err.Error())
+	}
+
+	// Check the message version against the policy
+	if msg.GetVersion() < hooks.RequiredMessageVersion() {
+		return errors.Wrapf(protocol.ErrMessageVersionNotSupported, "version %d is required", hooks.RequiredMessageVersion())
+	}
+
+	// Build the signing domain from the stub and the registered contract
+	domain, err := DomainFromStub(ctx.GetStub(), hooks.contractName)
+	if err != nil {
+		return err
+	}
+
+	// Validate Args
+	msgSigner, err := msg.VerifyAgainstArgs(domain, args[1:]...)
+	if err != nil {
+		return err
+	}
+
+	// Check the validity window against the transaction timestamp
+	if msg.ValidAfter != 0 || msg.ValidUntil != 0 {
+		txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+		if err != nil {
+			return err
+		}
+		if err = msg.CheckValidity(txTimestamp.GetSeconds()); err != nil {
+			return err
+		}
+	}
+
+	// Resolve the sender of a message signed by a session key
+	msgSender := msgSigner
+	if msg.Delegator != "" {
+		delegator, err := library.ParseAddress(msg.Delegator)
+		if err != nil {
+			return errors.Wrap(err, "invalid delegator")
+		}
+		if !hooks.spendingCapped {
+			return errors.Wrapf(ErrDelegationNotSupported, "%s does not cap spending", hooks.contractName)
+		}
+		if err = useDelegation(ctx, hooks.delegationStore, delegator.String(), msgSigner.String(), function); err != nil {
+			return err
+		}
+		msgSender = delegator
+	}
+
+	// Use the nonce so the message can not be replayed
+	if err = useNonce(ctx, hooks.nonceStore, msgSender.String(), msg.Nonce); err != nil {
+		return err
+	}
+
+	// set msg sender
+	ctx.SetMsgSender(msgSender)
+	ctx.SetMsgSigner(msgSigner)
+
+	return nil
+}
+
+// Spend spends amount of asset from the spending cap of the session key which signs the message.
+// asset tells apart the assets of the contract like the token IDs of ERC1155,which is empty if the contract has one asset.
+// The spending cap is spent per asset of each contract,so spending one token never spends the cap of another.
+// It does nothing if the message is signed by the sender itself.
+// Contracts declare `CapSpending` and call it before moving the sender's assets.
+func (hooks *Hooks) Spend(ctx ContextInterface, asset string, amount math.Uint256) error {
+	if ctx.MsgSigner() == ctx.MsgSender() {
+		return nil
+	}
+	if hooks.delegationStore == nil {
+		return ErrDelegationNotSupported
+	}
+	delegation, err := hooks.delegationStore.GetDelegation(ctx, ctx.MsgSender().String(), ctx.MsgSigner().String())
+	if err != nil {
+		return err
+	}
+	if delegation == nil {
+		return ErrDelegationNotFound
+	}
+	asset = SpendingAsset(ctx.Self(), asset)
+	if !delegation.Spend(asset, amount) {
+		return errors.Wrapf(ErrSpendingCapExceeded, "%s of %s remaining", delegation.Remaining(asset), asset)
+	}
+	return hooks.delegationStore.PutDelegation(ctx, delegation)
+}
+
+// AfterTransaction writes the cached state of the transaction and flushes its events as one envelope.
+// A contract uses it by `contract.AfterTransaction = hooks.AfterTransaction`
+func (hooks *Hooks) AfterTransaction(ctx ContextInterface) error {
+	if err := ctx.State().Flush(); err != nil {
+		return err
+	}
+	return ctx.FlushEvents()
+}
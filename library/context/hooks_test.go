@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// testContract has functions with and without Message
+type testContract struct{}
+
+func (testContract) Transfer(ctx context.ContextInterface, msg context.Message, to string, amount string) error {
+	return nil
+}
+
+func (testContract) BalanceOf(ctx context.ContextInterface, account string, unused string) (uint64, error) {
+	return 0, nil
+}
+
+// namedContract is a testContract with a name
+type namedContract struct {
+	testContract
+}
+
+func (namedContract) GetName() string {
+	return "org.bestchains.com.TestContract"
+}
+
+// otherContract is a testContract with another name
+type otherContract struct {
+	testContract
+}
+
+func (otherContract) GetName() string {
+	return "org.bestchains.com.OtherContract"
+}
+
+// newTestHooks creates hooks with testContract registered
+func newTestHooks(opts ...context.HookOption) *context.Hooks {
+	hooks := context.NewHooks(opts...)
+	hooks.Register(new(testContract))
+	return hooks
+}
+
+func TestHooks(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	domain := protocol.NewDomain("channel", "erc20", "Transfer")
+
+	// newCtx signs a message of version and returns a context calling `Transfer` with it
+	newCtx := func(version protocol.MessageVersion) *context.Context {
+		msg := &context.Message{Nonce: 0, Version: version}
+		assert.NoError(t, msg.GenerateSignature(domain, privateKey, "to", "1"))
+		bytes, err := msg.Marshal()
+		assert.NoError(t, err)
+
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "Transfer", string(bytes), "to", "1"))
+		return ctx
+	}
+
+	t.Run("Legacy messages rejected by default", func(t *testing.T) {
+		hooks := newTestHooks()
+		assert.Equal(t, protocol.MessageV2, hooks.RequiredMessageVersion())
+		assert.ErrorIs(t, hooks.BeforeTransaction(newCtx(protocol.MessageV1)), protocol.ErrMessageVersionNotSupported)
+		assert.NoError(t, hooks.BeforeTransaction(newCtx(protocol.MessageV2)))
+	})
+
+	t.Run("Legacy messages accepted during migration", func(t *testing.T) {
+		hooks := newTestHooks(context.WithLegacyMessages())
+		assert.Equal(t, protocol.MessageV1, hooks.RequiredMessageVersion())
+		assert.NoError(t, hooks.BeforeTransaction(newCtx(protocol.MessageV1)))
+		assert.NoError(t, hooks.BeforeTransaction(newCtx(protocol.MessageV2)))
+
+		// a message signed by clients before messages were versioned
+		legacy := `{"nonce":0,"publicKey":"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEkCPEdcyQ7c1xdRq5ouyKqOQERZse24c6jFGROJt1dWIEWMFtyAVViufmilCQfskOVsGiUTxQyoMdJJ5FqKvifw==","signature":"MEQCIBbUXQjV8T7XlIr6pxyx1Omd8l9bj7LY2eA7QhpImP+oAiBBT+uMsa9hdiOMQL5Pz/ydiFgNuUkv5j8b1nBinW5sEg=="}`
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "Transfer", legacy, "to", "1"))
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+		assert.Equal(t, library.Address("0xa8ecc0cf6a95ff5f038d0754dd1217deabde6950"), ctx.MsgSender())
+
+		version, err := hooks.MessageVersion(newCtx(protocol.MessageV2))
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(protocol.MessageV1), version)
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		hooks := newTestHooks()
+		assert.True(t, hooks.IsSigned("Transfer"))
+		assert.True(t, hooks.IsSigned("contract:transfer"))
+		assert.False(t, hooks.IsSigned("BalanceOf"))
+		assert.False(t, hooks.IsSigned("Unknown"))
+	})
+
+	t.Run("Signed functions require a message", func(t *testing.T) {
+		hooks := newTestHooks()
+		for _, args := range [][]string{{}, {"to", "1"}, {"{", "to", "1"}} {
+			ctx := new(context.Context)
+			ctx.SetStub(newTestStub(t, "erc20", "Transfer", args...))
+			assert.ErrorIs(t, hooks.BeforeTransaction(ctx), context.ErrMissingMessage)
+		}
+
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "Transfer", `{"version":2}`, "to", "1"))
+		assert.ErrorIs(t, hooks.BeforeTransaction(ctx), protocol.ErrInvalidMessage)
+	})
+
+	t.Run("Unsigned functions never get a sender", func(t *testing.T) {
+		hooks := newTestHooks()
+		ctx := newCtx(protocol.MessageV2)
+		ctx.SetStub(newTestStub(t, "erc20", "BalanceOf", ctx.GetStub().(*testStub).args...))
+		ctx.SetMsgSender("0x2b5715a46e48462258fca67c53dee748f77755b6")
+
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+		assert.Equal(t, library.Address(""), ctx.MsgSender())
+	})
+	t.Run("Messages are bound to the registered contract", func(t *testing.T) {
+		msg := &context.Message{Nonce: 0}
+		assert.NoError(t, msg.GenerateSignature(protocol.NewDomain("channel", "erc20", "org.bestchains.com.TestContract:Transfer"), privateKey, "to", "1"))
+		bytes, err := msg.Marshal()
+		assert.NoError(t, err)
+
+		// both contracts are invoked as the default contract without a namespace
+		other := context.NewHooks()
+		other.Register(new(otherContract))
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "Transfer", string(bytes), "to", "1"))
+		assert.ErrorIs(t, other.BeforeTransaction(ctx), protocol.ErrInvalidMessage)
+
+		hooks := context.NewHooks()
+		hooks.Register(new(namedContract))
+		ctx = new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "Transfer", string(bytes), "to", "1"))
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+	})
+
+	t.Run("Self", func(t *testing.T) {
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "BalanceOf"))
+		assert.NoError(t, newTestHooks().BeforeTransaction(ctx))
+		assert.Equal(t, library.ZeroAddress, ctx.Self())
+
+		hooks := context.NewHooks()
+		hooks.Register(new(namedContract))
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+		self := library.ContractAddress("channel", "erc20", "org.bestchains.com.TestContract", "")
+		assert.Equal(t, self, ctx.Self())
+
+		hooks = context.NewHooks(context.WithContractSalt("escrow"))
+		hooks.Register(new(namedContract))
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+		assert.NotEqual(t, self, ctx.Self())
+		assert.Equal(t, library.ContractAddress("channel", "erc20", "org.bestchains.com.TestContract", "escrow"), ctx.Self())
+	})
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/bestchains/bestchains-contracts/library"
+)
+
+var (
+	ErrNoCallerIdentity       = errors.New("no caller identity")
+	ErrCallerIdentityMismatch = errors.New("caller identity mismatch")
+	ErrUnknownIdentityPolicy  = errors.New("unknown identity policy")
+)
+
+// IdentityPolicy decides who the caller of a transaction is in ownership and role checks,
+// which is the operator who submits the transaction and/or the sender who signs its message
+type IdentityPolicy uint8
+
+const (
+	// IdentitySenderElseOperator identifies the caller by the message sender of a signed call,
+	// and by the operator of an unsigned call
+	IdentitySenderElseOperator IdentityPolicy = iota
+	// IdentityOperator identifies the caller by the operator only
+	IdentityOperator
+	// IdentitySender identifies the caller by the message sender only,so unsigned calls have no caller
+	IdentitySender
+	// IdentitySenderAndOperator identifies the caller by the message sender which must be the operator as well
+	IdentitySenderAndOperator
+)
+
+func (policy IdentityPolicy) String() string {
+	switch policy {
+	case IdentitySenderElseOperator:
+		return "sender-else-operator"
+	case IdentityOperator:
+		return "operator"
+	case IdentitySender:
+		return "sender"
+	case IdentitySenderAndOperator:
+		return "sender-and-operator"
+	}
+	return "unknown"
+}
+
+// Identify returns the caller of the transaction by the policy
+func (policy IdentityPolicy) Identify(ctx ContextInterface) (library.Address, error) {
+	var caller library.Address
+
+	switch policy {
+	case IdentitySenderElseOperator:
+		caller = ctx.MsgSender()
+		if caller.EmptyAddress() {
+			caller = ctx.Operator()
+		}
+	case IdentityOperator:
+		caller = ctx.Operator()
+	case IdentitySender:
+		caller = ctx.MsgSender()
+	case IdentitySenderAndOperator:
+		caller = ctx.MsgSender()
+		if !caller.EmptyAddress() && !caller.Equal(ctx.Operator()) {
+			return library.ZeroAddress, errors.Wrapf(ErrCallerIdentityMismatch, "sender %s is not the operator %s", caller, ctx.Operator())
+		}
+	default:
+		return library.ZeroAddress, errors.Wrapf(ErrUnknownIdentityPolicy, "%d", policy)
+	}
+
+	if caller.EmptyAddress() {
+		return library.ZeroAddress, errors.Wrapf(ErrNoCallerIdentity, "by policy %s", policy)
+	}
+	return caller.Canonical(), nil
+}
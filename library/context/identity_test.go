@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context_test
+
+import (
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityPolicy(t *testing.T) {
+	stub := shimtest.NewMockStub("depository", nil)
+	stub.Creator = newTestCreator(t, "Org1MSP", `{"attrs":{}}`)
+
+	clientIdentity, err := cid.New(stub)
+	assert.NoError(t, err)
+
+	// newTestContext returns a context of the operator with the policy and the message sender
+	newTestContext := func(policy context.IdentityPolicy, sender library.Address) *context.Context {
+		ctx := new(context.Context)
+		ctx.SetStub(stub)
+		ctx.SetClientIdentity(clientIdentity)
+		ctx.SetIdentityPolicy(policy)
+		ctx.SetMsgSender(sender)
+		return ctx
+	}
+
+	operator := newTestContext(context.IdentityOperator, "").Operator()
+	assert.False(t, operator.EmptyAddress())
+	sender := library.Address("0x2b5715a46e48462258fca67c53dee748f77755b6")
+
+	tests := []struct {
+		name   string
+		policy context.IdentityPolicy
+		sender library.Address
+		caller library.Address
+		err    error
+	}{
+		{"Sender else operator of a signed call", context.IdentitySenderElseOperator, sender, sender, nil},
+		{"Sender else operator of an unsigned call", context.IdentitySenderElseOperator, "", operator, nil},
+		{"Operator of a signed call", context.IdentityOperator, sender, operator, nil},
+		{"Operator of an unsigned call", context.IdentityOperator, "", operator, nil},
+		{"Sender of a signed call", context.IdentitySender, sender, sender, nil},
+		{"Sender of an unsigned call", context.IdentitySender, "", library.ZeroAddress, context.ErrNoCallerIdentity},
+		{"Sender and operator which match", context.IdentitySenderAndOperator, operator, operator, nil},
+		{"Sender and operator which mismatch", context.IdentitySenderAndOperator, sender, library.ZeroAddress, context.ErrCallerIdentityMismatch},
+		{"Sender and operator of an unsigned call", context.IdentitySenderAndOperator, "", library.ZeroAddress, context.ErrNoCallerIdentity},
+		{"Unknown policy", context.IdentityPolicy(255), sender, library.ZeroAddress, context.ErrUnknownIdentityPolicy},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			caller, err := newTestContext(test.policy, test.sender).Caller()
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.caller, caller)
+		})
+	}
+
+	t.Run("Hooks", func(t *testing.T) {
+		ctx := newTestContext(context.IdentitySenderElseOperator, "")
+		stub.MockTransactionStart("tx")
+		defer stub.MockTransactionEnd("tx")
+
+		hooks := newTestHooks(context.WithIdentityPolicy(context.IdentitySender))
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+		_, err := ctx.Caller()
+		assert.ErrorIs(t, err, context.ErrNoCallerIdentity)
+	})
+}
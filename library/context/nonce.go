@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/pkg/errors"
+)
+
+const (
+	// NoncePrefix is the composite key prefix of the nonces kept by LedgerNonceStore
+	NoncePrefix = "nonce~account"
+)
+
+var (
+	// ErrInvalidNonce is returned when the message nonce is not the current nonce of its sender.
+	ErrInvalidNonce = errors.New("nonce mistmatch")
+)
+
+// NonceStore keeps the nonce of each message sender.
+// `BeforeTransaction` requires the message nonce to be the sender's current nonce and then increments it,
+// so a signed message can only be executed once.
+type NonceStore interface {
+	// Current returns the current nonce of the account
+	Current(ctx ContextInterface, account string) (uint64, error)
+	// Increment increments the nonce of the account and returns the new nonce
+	Increment(ctx ContextInterface, account string) (uint64, error)
+}
+
+// LedgerNonceStore keeps nonces in the world state under `NoncePrefix`
+// regardless of the case of account addresses,which is the state `NonceContract` exposes.
+// Nonces are written to `ctx.State()` and flushed by `AfterTransaction` like any other state.
+type LedgerNonceStore struct{}
+
+var _ NonceStore = LedgerNonceStore{}
+
+func (LedgerNonceStore) Current(ctx ContextInterface, account string) (uint64, error) {
+	return nonceCounter(ctx, account).Current()
+}
+
+func (LedgerNonceStore) Increment(ctx ContextInterface, account string) (uint64, error) {
+	counter := nonceCounter(ctx, account)
+	curr, err := counter.Current()
+	if err != nil {
+		return 0, err
+	}
+	if err = counter.Increment(1); err != nil {
+		return 0, err
+	}
+	return curr + 1, nil
+}
+
+// nonceCounter returns the nonce counter of the account
+func nonceCounter(ctx ContextInterface, account string) *library.PersistentCounter {
+	return library.NewPersistentCounter(ctx.State().CounterStore(), NoncePrefix, library.Address(account).Canonical().String())
+}
+
+// useNonce checks the message nonce against the sender's current nonce and increments it
+func useNonce(ctx ContextInterface, store NonceStore, account string, nonce uint64) error {
+	curr, err := store.Current(ctx, account)
+	if err != nil {
+		return err
+	}
+	if curr != nonce {
+		return errors.Wrapf(ErrInvalidNonce, "expect %d but got %d", curr, nonce)
+	}
+	_, err = store.Increment(ctx, account)
+	return err
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryNonceStore keeps nonces in memory
+type memoryNonceStore map[string]uint64
+
+func (store memoryNonceStore) Current(ctx context.ContextInterface, account string) (uint64, error) {
+	return store[account], nil
+}
+
+func (store memoryNonceStore) Increment(ctx context.ContextInterface, account string) (uint64, error) {
+	store[account]++
+	return store[account], nil
+}
+
+func TestNonce(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	var sender = new(library.Address)
+	assert.NoError(t, sender.FromPublicKey(&privateKey.PublicKey))
+
+	domain := protocol.NewDomain("channel", "erc20", "Transfer")
+
+	// signedArgs signs a message with nonce and returns the stub arguments
+	signedArgs := func(nonce uint64) []string {
+		msg := &context.Message{Nonce: nonce}
+		assert.NoError(t, msg.GenerateSignature(domain, privateKey, "to", "1"))
+		bytes, err := msg.Marshal()
+		assert.NoError(t, err)
+		return []string{string(bytes), "to", "1"}
+	}
+
+	t.Run("Replayed message", func(t *testing.T) {
+		hooks := newTestHooks()
+		stub := newTestStub(t, "erc20", "Transfer", signedArgs(0)...)
+		ctx := new(context.Context)
+		ctx.SetStub(stub)
+
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+		curr, err := context.LedgerNonceStore{}.Current(ctx, sender.String())
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), curr)
+
+		// nonces are kept regardless of the case of addresses
+		curr, err = context.LedgerNonceStore{}.Current(ctx, strings.ToUpper(sender.String()))
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), curr)
+
+		// the same message can not be executed twice
+		assert.ErrorIs(t, hooks.BeforeTransaction(ctx), context.ErrInvalidNonce)
+
+		stub.args = signedArgs(1)
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+	})
+
+	t.Run("Future nonce", func(t *testing.T) {
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "Transfer", signedArgs(1)...))
+		assert.ErrorIs(t, newTestHooks().BeforeTransaction(ctx), context.ErrInvalidNonce)
+		assert.Equal(t, library.Address(""), ctx.MsgSender())
+	})
+
+	t.Run("WithNonceStore", func(t *testing.T) {
+		store := memoryNonceStore{sender.String(): 5}
+		hooks := newTestHooks(context.WithNonceStore(store))
+
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "Transfer", signedArgs(5)...))
+		assert.NoError(t, hooks.BeforeTransaction(ctx))
+		assert.Equal(t, uint64(6), store[sender.String()])
+
+		// nothing is written to the ledger
+		curr, err := context.LedgerNonceStore{}.Current(ctx, sender.String())
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), curr)
+	})
+}
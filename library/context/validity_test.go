@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeforeTransactionValidity(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	domain := protocol.NewDomain("channel", "erc20", "Transfer")
+
+	now := time.Now().Unix()
+	for _, test := range []struct {
+		validAfter int64
+		validUntil int64
+		err        error
+	}{
+		{validAfter: now - 60, validUntil: now + 60},
+		{validUntil: now - 60, err: protocol.ErrMessageExpired},
+		{validAfter: now + 60, err: protocol.ErrMessageNotYetValid},
+	} {
+		msg := &protocol.Message{Nonce: 0, ValidAfter: test.validAfter, ValidUntil: test.validUntil}
+		assert.NoError(t, msg.GenerateSignature(domain, privateKey, "to", "1"))
+		bytes, err := msg.Marshal()
+		assert.NoError(t, err)
+
+		ctx := new(context.Context)
+		ctx.SetStub(newTestStub(t, "erc20", "Transfer", string(bytes), "to", "1"))
+		err = newTestHooks().BeforeTransaction(ctx)
+		if test.err == nil {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, test.err)
+		}
+	}
+}
@@ -18,6 +18,8 @@ package initializable
 
 import (
 	"errors"
+	"math"
+	"strconv"
 
 	"github.com/bestchains/bestchains-contracts/library/context"
 )
@@ -25,23 +27,168 @@ import (
 type Status string
 
 const (
+	// Initialized is the value of a key initialized by TryInitialize,which is version 1
 	Initialized Status = "Initialized"
 )
 
+const (
+	// InitialVersion is the version TryInitialize initializes
+	InitialVersion uint64 = 1
+	// DisabledVersion is the version set by DisableInitializers,which no initializer can reach
+	DisabledVersion uint64 = math.MaxUint64
+)
+
 var (
-	ErrAlreadyInitialized = errors.New("already initialized")
+	ErrAlreadyInitialized   = errors.New("already initialized")
+	ErrInvalidVersion       = errors.New("invalid initialized version")
+	ErrUnorderedMigrations  = errors.New("migrations are not in increasing order of versions")
+	ErrInitializersDisabled = errors.New("initializers disabled")
+	// ErrNoInitializerPolicy is returned when Reinitialize,Migrate or DisableInitializers runs without any policy
+	ErrNoInitializerPolicy = errors.New("no initializer policy")
 )
 
-type Initializable struct{}
+// Initializable records the version initialized against keys.
+// Its zero value allows anyone to initialize,but never to reinitialize,migrate or disable initializers.
+type Initializable struct {
+	// policies must all pass before any initializer runs
+	policies []Policy
+}
+
+// NewInitializable creates an Initializable which only allows the operators passing all policies to initialize
+func NewInitializable(policies ...Policy) *Initializable {
+	return &Initializable{policies: policies}
+}
+
+// checkPolicies checks the operator against all policies
+func (init *Initializable) checkPolicies(ctx context.ContextInterface) error {
+	for _, policy := range init.policies {
+		if err := policy(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkUpgradePolicies requires at least one policy and checks the operator against all of them.
+// Reinitialize,Migrate and DisableInitializers run on a deployed contract,
+// so unlike the first initialization they are never open to anyone.
+func (init *Initializable) checkUpgradePolicies(ctx context.ContextInterface) error {
+	if len(init.policies) == 0 {
+		return ErrNoInitializerPolicy
+	}
+	return init.checkPolicies(ctx)
+}
 
 // Initializable checks whether this has been initialied agains that key
 func (init *Initializable) TryInitialize(ctx context.ContextInterface, key string) error {
-	val, err := ctx.GetStub().GetState(key)
+	if err := init.checkPolicies(ctx); err != nil {
+		return err
+	}
+	version, err := init.GetInitializedVersion(ctx, key)
 	if err != nil {
 		return err
 	}
-	if string(val) == string(Initialized) {
+	if version == DisabledVersion {
+		return ErrInitializersDisabled
+	}
+	if version >= InitialVersion {
 		return ErrAlreadyInitialized
 	}
-	return ctx.GetStub().PutState(key, []byte(Initialized))
+	return ctx.State().Put(key, []byte(Initialized))
+}
+
+// GetInitializedVersion returns the version initialized against that key,
+// which is 0 if it is never initialized and 1 if it is initialized by TryInitialize
+func (init *Initializable) GetInitializedVersion(ctx context.ContextInterface, key string) (uint64, error) {
+	val, err := ctx.State().Get(key)
+	if err != nil {
+		return 0, err
+	}
+	switch string(val) {
+	case "":
+		return 0, nil
+	case string(Initialized):
+		return InitialVersion, nil
+	}
+	version, err := strconv.ParseUint(string(val), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidVersion
+	}
+	return version, nil
+}
+
+// Reinitialize initializes that key to the version,which runs only once for every increasing version.
+// It is used after an upgrade,for example to backfill states the previous version never kept.
+// It fails with ErrNoInitializerPolicy if this is created without policies.
+func (init *Initializable) Reinitialize(ctx context.ContextInterface, key string, version uint64) error {
+	if version == 0 || version == DisabledVersion {
+		return ErrInvalidVersion
+	}
+	if err := init.checkUpgradePolicies(ctx); err != nil {
+		return err
+	}
+	current, err := init.GetInitializedVersion(ctx, key)
+	if err != nil {
+		return err
+	}
+	if current == DisabledVersion {
+		return ErrInitializersDisabled
+	}
+	if current >= version {
+		return ErrAlreadyInitialized
+	}
+	return ctx.State().Put(key, []byte(strconv.FormatUint(version, 10)))
+}
+
+// DisableInitializers prevents that key from being initialized or reinitialized again.
+// It fails with ErrNoInitializerPolicy if this is created without policies.
+func (init *Initializable) DisableInitializers(ctx context.ContextInterface, key string) error {
+	if err := init.checkUpgradePolicies(ctx); err != nil {
+		return err
+	}
+	return ctx.State().Put(key, []byte(strconv.FormatUint(DisabledVersion, 10)))
+}
+
+// Migration is a step to upgrade the states to its version
+type Migration struct {
+	Version uint64
+	Migrate func(ctx context.ContextInterface) error
+}
+
+// Migrate runs the migrations whose versions are above the version initialized against that key in order,
+// and then initializes that key to the last version.
+// Migrations must be in increasing order of versions,and a failed migration fails all of them with the transaction.
+// It fails with ErrNoInitializerPolicy if this is created without policies.
+func (init *Initializable) Migrate(ctx context.ContextInterface, key string, migrations ...Migration) error {
+	if err := init.checkUpgradePolicies(ctx); err != nil {
+		return err
+	}
+	current, err := init.GetInitializedVersion(ctx, key)
+	if err != nil {
+		return err
+	}
+	if current == DisabledVersion {
+		return ErrInitializersDisabled
+	}
+
+	var previous uint64
+	for _, migration := range migrations {
+		if migration.Version <= previous || migration.Version == DisabledVersion {
+			return ErrUnorderedMigrations
+		}
+		previous = migration.Version
+	}
+	if previous <= current {
+		return ErrAlreadyInitialized
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+		if err = migration.Migrate(ctx); err != nil {
+			return err
+		}
+	}
+	return ctx.State().Put(key, []byte(strconv.FormatUint(previous, 10)))
 }
@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package initializable_test
+
+import (
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/bestchains/bestchains-contracts/library/initializable"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKey = "test~initialized"
+
+func newCtx() *context.Context {
+	stub := shimtest.NewMockStub("initializable", nil)
+	stub.MockTransactionStart("tx")
+	ctx := new(context.Context)
+	ctx.SetStub(stub)
+	return ctx
+}
+
+// allowAll is a policy which passes any operator
+func allowAll(ctx context.ContextInterface) error {
+	return nil
+}
+
+func TestInitializable(t *testing.T) {
+	init := initializable.NewInitializable(allowAll)
+
+	t.Run("TryInitialize", func(t *testing.T) {
+		ctx := newCtx()
+		version, err := init.GetInitializedVersion(ctx, testKey)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), version)
+
+		assert.NoError(t, init.TryInitialize(ctx, testKey))
+		assert.ErrorIs(t, init.TryInitialize(ctx, testKey), initializable.ErrAlreadyInitialized)
+		version, err = init.GetInitializedVersion(ctx, testKey)
+		assert.NoError(t, err)
+		assert.Equal(t, initializable.InitialVersion, version)
+	})
+
+	t.Run("Reinitialize", func(t *testing.T) {
+		ctx := newCtx()
+		assert.NoError(t, init.TryInitialize(ctx, testKey))
+		assert.ErrorIs(t, init.Reinitialize(ctx, testKey, 1), initializable.ErrAlreadyInitialized)
+		assert.NoError(t, init.Reinitialize(ctx, testKey, 3))
+		assert.ErrorIs(t, init.Reinitialize(ctx, testKey, 2), initializable.ErrAlreadyInitialized)
+		assert.ErrorIs(t, init.Reinitialize(ctx, testKey, 3), initializable.ErrAlreadyInitialized)
+		assert.ErrorIs(t, init.TryInitialize(ctx, testKey), initializable.ErrAlreadyInitialized)
+		assert.ErrorIs(t, init.Reinitialize(ctx, testKey, 0), initializable.ErrInvalidVersion)
+
+		version, err := init.GetInitializedVersion(ctx, testKey)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(3), version)
+	})
+
+	t.Run("DisableInitializers", func(t *testing.T) {
+		ctx := newCtx()
+		assert.NoError(t, init.DisableInitializers(ctx, testKey))
+		assert.ErrorIs(t, init.TryInitialize(ctx, testKey), initializable.ErrInitializersDisabled)
+		assert.ErrorIs(t, init.Reinitialize(ctx, testKey, 2), initializable.ErrInitializersDisabled)
+		assert.ErrorIs(t, init.Migrate(ctx, testKey), initializable.ErrInitializersDisabled)
+
+		version, err := init.GetInitializedVersion(ctx, testKey)
+		assert.NoError(t, err)
+		assert.Equal(t, initializable.DisabledVersion, version)
+	})
+
+	t.Run("Migrate", func(t *testing.T) {
+		ctx := newCtx()
+		var steps []uint64
+		step := func(version uint64) initializable.Migration {
+			return initializable.Migration{Version: version, Migrate: func(ctx context.ContextInterface) error {
+				steps = append(steps, version)
+				return nil
+			}}
+		}
+
+		assert.NoError(t, init.Reinitialize(ctx, testKey, 2))
+		assert.ErrorIs(t, init.Migrate(ctx, testKey, step(3), step(3)), initializable.ErrUnorderedMigrations)
+		assert.ErrorIs(t, init.Migrate(ctx, testKey, step(4), step(3)), initializable.ErrUnorderedMigrations)
+		assert.Empty(t, steps)
+
+		assert.NoError(t, init.Migrate(ctx, testKey, step(1), step(2), step(3), step(5)))
+		assert.Equal(t, []uint64{3, 5}, steps)
+		version, err := init.GetInitializedVersion(ctx, testKey)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(5), version)
+
+		assert.ErrorIs(t, init.Migrate(ctx, testKey, step(1), step(5)), initializable.ErrAlreadyInitialized)
+		assert.Equal(t, []uint64{3, 5}, steps)
+	})
+}
+
+// operatorCtx fakes the identity of the operator
+type operatorCtx struct {
+	*context.Context
+
+	operator library.Address
+	mspID    string
+	attrs    map[string]string
+}
+
+func (ctx *operatorCtx) Operator() library.Address {
+	return ctx.operator
+}
+
+func (ctx *operatorCtx) OperatorMSPID() (string, error) {
+	return ctx.mspID, nil
+}
+
+func (ctx *operatorCtx) AssertOperatorAttribute(name, value string) error {
+	if ctx.attrs[name] != value {
+		return context.ErrOperatorAttributeMismatch
+	}
+	return nil
+}
+
+func TestPolicy(t *testing.T) {
+	initializer := library.Address("0x2b5715a46e48462258fca67c53dee748f77755b6")
+	newOperatorCtx := func(operator library.Address, mspID string) *operatorCtx {
+		return &operatorCtx{Context: newCtx(), operator: operator, mspID: mspID, attrs: map[string]string{"role": "admin"}}
+	}
+
+	t.Run("Initializer hash", func(t *testing.T) {
+		init := initializable.NewInitializable(initializable.RequireInitializerHash(initializable.InitializerHash(initializer)))
+
+		ctx := newOperatorCtx("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "Org1MSP")
+		assert.ErrorIs(t, init.TryInitialize(ctx, testKey), initializable.ErrUnauthorizedInitializer)
+		assert.ErrorIs(t, init.Reinitialize(ctx, testKey, 2), initializable.ErrUnauthorizedInitializer)
+		assert.ErrorIs(t, init.DisableInitializers(ctx, testKey), initializable.ErrUnauthorizedInitializer)
+		assert.ErrorIs(t, init.Migrate(ctx, testKey), initializable.ErrUnauthorizedInitializer)
+
+		ctx = newOperatorCtx(initializer, "Org1MSP")
+		assert.NoError(t, init.TryInitialize(ctx, testKey))
+	})
+
+	t.Run("MSP ID and attribute", func(t *testing.T) {
+		init := initializable.NewInitializable(initializable.RequireMSPID("Org1MSP"), initializable.RequireAttribute("role", "admin"))
+
+		assert.ErrorIs(t, init.TryInitialize(newOperatorCtx(initializer, "Org2MSP"), testKey), initializable.ErrUnauthorizedInitializer)
+
+		ctx := newOperatorCtx(initializer, "Org1MSP")
+		ctx.attrs["role"] = "member"
+		assert.ErrorIs(t, init.TryInitialize(ctx, testKey), initializable.ErrUnauthorizedInitializer)
+
+		assert.NoError(t, init.TryInitialize(newOperatorCtx(initializer, "Org1MSP"), testKey))
+	})
+
+	t.Run("Policies from build", func(t *testing.T) {
+		assert.Empty(t, initializable.PoliciesFromBuild("", ""))
+
+		init := initializable.NewInitializable(initializable.PoliciesFromBuild(initializable.InitializerHash(initializer), "Org1MSP")...)
+		assert.ErrorIs(t, init.TryInitialize(newOperatorCtx(initializer, "Org2MSP"), testKey), initializable.ErrUnauthorizedInitializer)
+		assert.ErrorIs(t, init.TryInitialize(newOperatorCtx("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "Org1MSP"), testKey), initializable.ErrUnauthorizedInitializer)
+		assert.NoError(t, init.TryInitialize(newOperatorCtx(initializer, "Org1MSP"), testKey))
+	})
+
+	t.Run("Zero value allows anyone", func(t *testing.T) {
+		assert.NoError(t, new(initializable.Initializable).TryInitialize(newOperatorCtx(initializer, "Org2MSP"), testKey))
+	})
+
+	t.Run("Zero value can not upgrade", func(t *testing.T) {
+		init := new(initializable.Initializable)
+		ctx := newOperatorCtx(initializer, "Org1MSP")
+		assert.ErrorIs(t, init.Reinitialize(ctx, testKey, 2), initializable.ErrNoInitializerPolicy)
+		assert.ErrorIs(t, init.DisableInitializers(ctx, testKey), initializable.ErrNoInitializerPolicy)
+		assert.ErrorIs(t, init.Migrate(ctx, testKey), initializable.ErrNoInitializerPolicy)
+	})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package initializable
+
+import (
+	"encoding/hex"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/context"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	ErrUnauthorizedInitializer = errors.New("unauthorized initializer")
+)
+
+// Policy tells whether the operator of the transaction may initialize a contract.
+// It returns an error wrapping ErrUnauthorizedInitializer if not.
+type Policy func(ctx context.ContextInterface) error
+
+// RequireMSPID only allows operators of the organization with the MSP ID to initialize
+func RequireMSPID(mspID string) Policy {
+	return func(ctx context.ContextInterface) error {
+		operatorMSPID, err := ctx.OperatorMSPID()
+		if err != nil {
+			return errors.Wrap(ErrUnauthorizedInitializer, err.Error())
+		}
+		if operatorMSPID != mspID {
+			return errors.Wrapf(ErrUnauthorizedInitializer, "MSP ID %s is not %s", operatorMSPID, mspID)
+		}
+		return nil
+	}
+}
+
+// RequireAttribute only allows operators whose certificate has the attribute of the value to initialize
+func RequireAttribute(name string, value string) Policy {
+	return func(ctx context.ContextInterface) error {
+		if err := ctx.AssertOperatorAttribute(name, value); err != nil {
+			return errors.Wrap(ErrUnauthorizedInitializer, err.Error())
+		}
+		return nil
+	}
+}
+
+// RequireInitializerHash only allows the operator whose address hashes to the pre-committed hash(`InitializerHash`) to initialize,
+// so the initializer is fixed when the chaincode is built without revealing its address
+func RequireInitializerHash(hash string) Policy {
+	return func(ctx context.ContextInterface) error {
+		if InitializerHash(ctx.Operator()) != hash {
+			return errors.Wrap(ErrUnauthorizedInitializer, "initializer hash mismatch")
+		}
+		return nil
+	}
+}
+
+// PoliciesFromBuild returns the policies of the initializer set when the chaincode is built,
+// which require the pre-committed hash of the initializer's address and its MSP ID if they are not empty
+func PoliciesFromBuild(hash string, mspID string) []Policy {
+	var policies []Policy
+	if hash != "" {
+		policies = append(policies, RequireInitializerHash(hash))
+	}
+	if mspID != "" {
+		policies = append(policies, RequireMSPID(mspID))
+	}
+	return policies
+}
+
+// InitializerHash returns the hex encoded keccak256 hash of the canonical address,
+// which is committed by `RequireInitializerHash`
+func InitializerHash(addr library.Address) string {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(addr.Canonical().Bytes())
+	return hex.EncodeToString(hasher.Sum(nil))
+}
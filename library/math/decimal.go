@@ -0,0 +1,252 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package math
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidDecimal = errors.New("invalid decimal")
+	ErrInvalidScale   = errors.New("invalid decimal scale")
+)
+
+// MaxScale is the largest scale of a Decimal,as 10^77 is the largest power of 10 in Uint256
+const MaxScale uint8 = 77
+
+// RoundingMode tells how a Decimal is rounded when digits beyond its scale are dropped
+type RoundingMode uint8
+
+const (
+	// RoundDown rounds towards zero
+	RoundDown RoundingMode = iota
+	// RoundUp rounds away from zero
+	RoundUp
+	// RoundHalfEven rounds to the nearest and to the even neighbor on ties,which is the banker's rounding
+	RoundHalfEven
+)
+
+var decimalRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// Decimal is a non-negative fixed-point decimal of `coefficient / 10^scale`.
+// All operations are deterministic and checked,
+// and digits beyond the scale are only dropped by an explicit RoundingMode.
+type Decimal struct {
+	coefficient Uint256
+	scale       uint8
+}
+
+// NewDecimal creates the Decimal of `coefficient / 10^scale`
+func NewDecimal(coefficient Uint256, scale uint8) (Decimal, error) {
+	if scale > MaxScale {
+		return Decimal{}, errors.Wrapf(ErrInvalidScale, "%d is larger than %d", scale, MaxScale)
+	}
+	return Decimal{coefficient: coefficient, scale: scale}, nil
+}
+
+// ParseDecimal parses a decimal string like `12.345` into a Decimal of the scale,
+// rounding the digits beyond the scale by mode
+func ParseDecimal(s string, scale uint8, mode RoundingMode) (Decimal, error) {
+	if scale > MaxScale {
+		return Decimal{}, errors.Wrapf(ErrInvalidScale, "%d is larger than %d", scale, MaxScale)
+	}
+	if !decimalRegexp.MatchString(s) {
+		return Decimal{}, errors.Wrapf(ErrInvalidDecimal, "not a decimal: %q", s)
+	}
+
+	digits, fraction := s, ""
+	if index := strings.IndexByte(s, '.'); index != -1 {
+		digits, fraction = s[:index], s[index+1:]
+	}
+	coefficient, _ := new(big.Int).SetString(digits+fraction, 10)
+
+	rescaled := rescale(coefficient, len(fraction), int(scale), mode)
+	x, ok := fromBig(rescaled)
+	if !ok {
+		return Decimal{}, errors.Wrapf(ErrInvalidDecimal, "overflowed: %s", s)
+	}
+	return Decimal{coefficient: x, scale: scale}, nil
+}
+
+// FromBaseUnits converts an amount in the base units of a token with decimals(`IERC20.Decimal()`) to a Decimal,
+// for example 525 base units of a token with 2 decimals is 5.25
+func FromBaseUnits(amount Uint256, decimals uint8) (Decimal, error) {
+	return NewDecimal(amount, decimals)
+}
+
+// ToBaseUnits converts d to an amount in the base units of a token with decimals(`IERC20.Decimal()`),
+// rounding the digits beyond the decimals by mode
+func (d Decimal) ToBaseUnits(decimals uint8, mode RoundingMode) (bool, Uint256) {
+	ok, rescaled := d.Rescale(decimals, mode)
+	if !ok {
+		return false, Uint256{}
+	}
+	return true, rescaled.coefficient
+}
+
+// Coefficient returns the integer coefficient of d,which is d in units of 10^-scale
+func (d Decimal) Coefficient() Uint256 {
+	return d.coefficient
+}
+
+// Scale returns the number of digits after the decimal point
+func (d Decimal) Scale() uint8 {
+	return d.scale
+}
+
+// IsZero tells whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.coefficient.IsZero()
+}
+
+// String returns d with exactly scale digits after the decimal point,like `5.250`
+func (d Decimal) String() string {
+	digits := d.coefficient.String()
+	if d.scale == 0 {
+		return digits
+	}
+	if len(digits) <= int(d.scale) {
+		digits = strings.Repeat("0", int(d.scale)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(d.scale)
+	return digits[:point] + "." + digits[point:]
+}
+
+// Cmp compares d and y regardless of their scales and returns -1 if d < y, 0 if d == y and +1 if d > y
+func (d Decimal) Cmp(y Decimal) int {
+	scale := maxScale(d.scale, y.scale)
+	return rescale(d.coefficient.big(), int(d.scale), int(scale), RoundDown).
+		Cmp(rescale(y.coefficient.big(), int(y.scale), int(scale), RoundDown))
+}
+
+// Rescale returns d in the scale,rounding the digits beyond the scale by mode
+func (d Decimal) Rescale(scale uint8, mode RoundingMode) (bool, Decimal) {
+	if scale > MaxScale {
+		return false, Decimal{}
+	}
+	x, ok := fromBig(rescale(d.coefficient.big(), int(d.scale), int(scale), mode))
+	if !ok {
+		return false, Decimal{}
+	}
+	return true, Decimal{coefficient: x, scale: scale}
+}
+
+// TryAdd returns d+y in the larger scale of d and y,which is exact
+func (d Decimal) TryAdd(y Decimal) (bool, Decimal) {
+	scale := maxScale(d.scale, y.scale)
+	ok, x, y := alignScale(d, y, scale)
+	if !ok {
+		return false, Decimal{}
+	}
+	ok, sum := x.coefficient.TryAdd(y.coefficient)
+	if !ok {
+		return false, Decimal{}
+	}
+	return true, Decimal{coefficient: sum, scale: scale}
+}
+
+// TrySub returns d-y in the larger scale of d and y,which is exact
+func (d Decimal) TrySub(y Decimal) (bool, Decimal) {
+	scale := maxScale(d.scale, y.scale)
+	ok, x, y := alignScale(d, y, scale)
+	if !ok {
+		return false, Decimal{}
+	}
+	ok, difference := x.coefficient.TrySub(y.coefficient)
+	if !ok {
+		return false, Decimal{}
+	}
+	return true, Decimal{coefficient: difference, scale: scale}
+}
+
+// TryMul returns d*y in the scale of d,rounding the digits beyond it by mode
+func (d Decimal) TryMul(y Decimal, mode RoundingMode) (bool, Decimal) {
+	product := new(big.Int).Mul(d.coefficient.big(), y.coefficient.big())
+	x, ok := fromBig(rescale(product, int(d.scale)+int(y.scale), int(d.scale), mode))
+	if !ok {
+		return false, Decimal{}
+	}
+	return true, Decimal{coefficient: x, scale: d.scale}
+}
+
+// TryDiv returns d/y in the scale of d,rounding the digits beyond it by mode
+func (d Decimal) TryDiv(y Decimal, mode RoundingMode) (bool, Decimal) {
+	if y.IsZero() {
+		return false, Decimal{}
+	}
+	dividend := new(big.Int).Mul(d.coefficient.big(), pow10(int(y.scale)))
+	x, ok := fromBig(divRound(dividend, y.coefficient.big(), mode))
+	if !ok {
+		return false, Decimal{}
+	}
+	return true, Decimal{coefficient: x, scale: d.scale}
+}
+
+func maxScale(x, y uint8) uint8 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+// alignScale rescales x and y to the scale which is not smaller than theirs
+func alignScale(x, y Decimal, scale uint8) (bool, Decimal, Decimal) {
+	ok, x := x.Rescale(scale, RoundDown)
+	if !ok {
+		return false, Decimal{}, Decimal{}
+	}
+	ok, y = y.Rescale(scale, RoundDown)
+	if !ok {
+		return false, Decimal{}, Decimal{}
+	}
+	return true, x, y
+}
+
+// rescale converts a coefficient from a scale to another,rounding the dropped digits by mode
+func rescale(coefficient *big.Int, from int, to int, mode RoundingMode) *big.Int {
+	if to >= from {
+		return new(big.Int).Mul(coefficient, pow10(to-from))
+	}
+	return divRound(coefficient, pow10(from-to), mode)
+}
+
+// divRound divides the non-negative n by the positive d,rounding the remainder by mode
+func divRound(n *big.Int, d *big.Int, mode RoundingMode) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(n, d, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	switch mode {
+	case RoundUp:
+		return quotient.Add(quotient, big.NewInt(1))
+	case RoundHalfEven:
+		half := new(big.Int).Lsh(remainder, 1).Cmp(d)
+		if half > 0 || (half == 0 && quotient.Bit(0) == 1) {
+			return quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package math_test
+
+import (
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustDecimal(t *testing.T, s string, scale uint8) math.Decimal {
+	d, err := math.ParseDecimal(s, scale, math.RoundDown)
+	assert.NoError(t, err)
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	d := mustDecimal(t, "5.25", 3)
+	assert.Equal(t, "5.250", d.String())
+	assert.Equal(t, math.NewUint256(5250), d.Coefficient())
+	assert.Equal(t, uint8(3), d.Scale())
+
+	assert.Equal(t, "0.05", mustDecimal(t, "0.05", 2).String())
+	assert.Equal(t, "12", mustDecimal(t, "12", 0).String())
+
+	for _, c := range []struct {
+		s        string
+		mode     math.RoundingMode
+		expected string
+	}{
+		{"1.25", math.RoundDown, "1.2"},
+		{"1.21", math.RoundUp, "1.3"},
+		{"1.25", math.RoundHalfEven, "1.2"},
+		{"1.35", math.RoundHalfEven, "1.4"},
+		{"1.251", math.RoundHalfEven, "1.3"},
+		{"1.20", math.RoundUp, "1.2"},
+	} {
+		d, err := math.ParseDecimal(c.s, 1, c.mode)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, d.String(), c.s)
+	}
+
+	for _, s := range []string{"", "-1", "1.", ".5", "1e3", "1,5"} {
+		_, err := math.ParseDecimal(s, 2, math.RoundDown)
+		assert.ErrorIs(t, err, math.ErrInvalidDecimal, s)
+	}
+	_, err := math.ParseDecimal("1", math.MaxScale+1, math.RoundDown)
+	assert.ErrorIs(t, err, math.ErrInvalidScale)
+	_, err = math.ParseDecimal("10", math.MaxScale, math.RoundDown)
+	assert.ErrorIs(t, err, math.ErrInvalidDecimal)
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	t.Run("Add and Sub", func(t *testing.T) {
+		ok, sum := mustDecimal(t, "1.5", 1).TryAdd(mustDecimal(t, "0.25", 2))
+		assert.True(t, ok)
+		assert.Equal(t, "1.75", sum.String())
+
+		ok, difference := sum.TrySub(mustDecimal(t, "1.5", 1))
+		assert.True(t, ok)
+		assert.Equal(t, "0.25", difference.String())
+
+		ok, _ = difference.TrySub(sum)
+		assert.False(t, ok)
+	})
+
+	t.Run("Mul", func(t *testing.T) {
+		amount := mustDecimal(t, "100.01", 2)
+		rate := mustDecimal(t, "0.025", 3)
+		ok, fee := amount.TryMul(rate, math.RoundDown)
+		assert.True(t, ok)
+		assert.Equal(t, "2.50", fee.String())
+		ok, fee = amount.TryMul(rate, math.RoundUp)
+		assert.True(t, ok)
+		assert.Equal(t, "2.51", fee.String())
+
+		max, err := math.NewDecimal(math.MaxUint256, 0)
+		assert.NoError(t, err)
+		ok, _ = max.TryMul(mustDecimal(t, "2", 0), math.RoundDown)
+		assert.False(t, ok)
+	})
+
+	t.Run("Div", func(t *testing.T) {
+		ok, quotient := mustDecimal(t, "1.00", 2).TryDiv(mustDecimal(t, "3", 0), math.RoundHalfEven)
+		assert.True(t, ok)
+		assert.Equal(t, "0.33", quotient.String())
+		ok, quotient = mustDecimal(t, "2.00", 2).TryDiv(mustDecimal(t, "3", 0), math.RoundDown)
+		assert.True(t, ok)
+		assert.Equal(t, "0.66", quotient.String())
+		ok, quotient = mustDecimal(t, "1.00", 2).TryDiv(mustDecimal(t, "0.5", 1), math.RoundDown)
+		assert.True(t, ok)
+		assert.Equal(t, "2.00", quotient.String())
+
+		ok, _ = quotient.TryDiv(math.Decimal{}, math.RoundDown)
+		assert.False(t, ok)
+	})
+
+	t.Run("Cmp", func(t *testing.T) {
+		assert.Equal(t, 0, mustDecimal(t, "1.5", 1).Cmp(mustDecimal(t, "1.50", 2)))
+		assert.Equal(t, -1, mustDecimal(t, "1.49", 2).Cmp(mustDecimal(t, "1.5", 1)))
+		assert.Equal(t, 1, mustDecimal(t, "2", 0).Cmp(mustDecimal(t, "1.99", 2)))
+	})
+}
+
+func TestDecimalBaseUnits(t *testing.T) {
+	d, err := math.FromBaseUnits(math.NewUint256(525), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "5.25", d.String())
+
+	ok, units := d.ToBaseUnits(18, math.RoundDown)
+	assert.True(t, ok)
+	assert.Equal(t, "5250000000000000000", units.String())
+
+	ok, units = mustDecimal(t, "5.255", 3).ToBaseUnits(2, math.RoundHalfEven)
+	assert.True(t, ok)
+	assert.Equal(t, math.NewUint256(526), units)
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package math
+
+import (
+	"encoding/json"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidUint256 = errors.New("invalid uint256")
+)
+
+// Uint256 is an unsigned 256-bit integer in little endian 64-bit words.
+// It is encoded as a decimal string,which is the same as the encoding of uint64,
+// so a value stored as a uint64 string is decoded as it is.
+type Uint256 [4]uint64
+
+var (
+	// MaxUint256 is the largest Uint256,2^256-1
+	MaxUint256 = Uint256{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}
+
+	maxUint256Big = MaxUint256.big()
+)
+
+// NewUint256 creates a Uint256 from a uint64
+func NewUint256(v uint64) Uint256 {
+	return Uint256{v}
+}
+
+// ParseUint256 parses a decimal string without sign
+func ParseUint256(s string) (Uint256, error) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return Uint256{}, errors.Wrapf(ErrInvalidUint256, "not a decimal: %q", s)
+	}
+	v, _ := new(big.Int).SetString(s, 10)
+	x, ok := fromBig(v)
+	if !ok {
+		return Uint256{}, errors.Wrapf(ErrInvalidUint256, "overflowed: %s", s)
+	}
+	return x, nil
+}
+
+// fromBig converts a big.Int which is in the range of Uint256
+func fromBig(v *big.Int) (Uint256, bool) {
+	if v.Sign() < 0 || v.Cmp(maxUint256Big) > 0 {
+		return Uint256{}, false
+	}
+	var x Uint256
+	words := v.Bits()
+	for i := 0; i < len(words); i++ {
+		// big.Word is 64-bit on the platforms fabric supports
+		x[i] = uint64(words[i])
+	}
+	return x, true
+}
+
+func (x Uint256) big() *big.Int {
+	words := make([]big.Word, len(x))
+	for i, word := range x {
+		words[i] = big.Word(word)
+	}
+	return new(big.Int).SetBits(words)
+}
+
+// String returns the decimal string of x
+func (x Uint256) String() string {
+	if x.IsUint64() {
+		return strconv.FormatUint(x[0], 10)
+	}
+	return x.big().String()
+}
+
+// IsZero tells whether x is 0
+func (x Uint256) IsZero() bool {
+	return x == Uint256{}
+}
+
+// IsUint64 tells whether x fits in a uint64
+func (x Uint256) IsUint64() bool {
+	return x[1] == 0 && x[2] == 0 && x[3] == 0
+}
+
+// Uint64 returns the lowest 64 bits of x
+func (x Uint256) Uint64() uint64 {
+	return x[0]
+}
+
+// Cmp compares x and y and returns -1 if x < y, 0 if x == y and +1 if x > y
+func (x Uint256) Cmp(y Uint256) int {
+	for i := len(x) - 1; i >= 0; i-- {
+		if x[i] < y[i] {
+			return -1
+		}
+		if x[i] > y[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (x Uint256) TryAdd(y Uint256) (bool, Uint256) {
+	var z Uint256
+	var carry uint64
+	for i := range x {
+		z[i], carry = bits.Add64(x[i], y[i], carry)
+	}
+	if carry != 0 {
+		return false, Uint256{}
+	}
+	return true, z
+}
+
+func (x Uint256) TrySub(y Uint256) (bool, Uint256) {
+	var z Uint256
+	var borrow uint64
+	for i := range x {
+		z[i], borrow = bits.Sub64(x[i], y[i], borrow)
+	}
+	if borrow != 0 {
+		return false, Uint256{}
+	}
+	return true, z
+}
+
+func (x Uint256) TryMul(y Uint256) (bool, Uint256) {
+	z, ok := fromBig(new(big.Int).Mul(x.big(), y.big()))
+	if !ok {
+		return false, Uint256{}
+	}
+	return true, z
+}
+
+func (x Uint256) TryDiv(y Uint256) (bool, Uint256) {
+	if y.IsZero() {
+		return false, Uint256{}
+	}
+	z, _ := fromBig(new(big.Int).Quo(x.big(), y.big()))
+	return true, z
+}
+
+func (x Uint256) TryMod(y Uint256) (bool, Uint256) {
+	if y.IsZero() {
+		return false, Uint256{}
+	}
+	z, _ := fromBig(new(big.Int).Rem(x.big(), y.big()))
+	return true, z
+}
+
+// MarshalJSON encodes x as a decimal string,as a JSON number can not hold 256 bits
+func (x Uint256) MarshalJSON() ([]byte, error) {
+	return json.Marshal(x.String())
+}
+
+// UnmarshalJSON decodes a decimal string or a JSON number
+func (x *Uint256) UnmarshalJSON(data []byte) error {
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	} else {
+		s = string(data)
+	}
+	v, err := ParseUint256(s)
+	if err != nil {
+		return err
+	}
+	*x = v
+	return nil
+}
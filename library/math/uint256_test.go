@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package math_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/stretchr/testify/assert"
+)
+
+const maxUint256 = "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+
+func mustParse(t *testing.T, s string) math.Uint256 {
+	x, err := math.ParseUint256(s)
+	assert.NoError(t, err)
+	return x
+}
+
+func TestParseUint256(t *testing.T) {
+	for _, s := range []string{"0", "1", "18446744073709551615", "18446744073709551616", maxUint256} {
+		assert.Equal(t, s, mustParse(t, s).String())
+	}
+	assert.Equal(t, math.MaxUint256, mustParse(t, maxUint256))
+	assert.Equal(t, math.NewUint256(100), mustParse(t, "0100"))
+
+	for _, s := range []string{"", "-1", "+1", "1.5", "0x10", " 1",
+		"115792089237316195423570985008687907853269984665640564039457584007913129639936"} {
+		_, err := math.ParseUint256(s)
+		assert.ErrorIs(t, err, math.ErrInvalidUint256, s)
+	}
+}
+
+func TestUint256Arithmetic(t *testing.T) {
+	one := math.NewUint256(1)
+	maxUint64 := math.NewUint256(18446744073709551615)
+
+	t.Run("Add", func(t *testing.T) {
+		ok, result := maxUint64.TryAdd(one)
+		assert.True(t, ok)
+		assert.Equal(t, "18446744073709551616", result.String())
+
+		ok, result = math.MaxUint256.TryAdd(one)
+		assert.False(t, ok)
+		assert.True(t, result.IsZero())
+	})
+
+	t.Run("Sub", func(t *testing.T) {
+		ok, result := mustParse(t, "18446744073709551616").TrySub(one)
+		assert.True(t, ok)
+		assert.Equal(t, maxUint64, result)
+
+		ok, _ = one.TrySub(math.NewUint256(2))
+		assert.False(t, ok)
+	})
+
+	t.Run("Mul", func(t *testing.T) {
+		ok, result := maxUint64.TryMul(maxUint64)
+		assert.True(t, ok)
+		assert.Equal(t, "340282366920938463426481119284349108225", result.String())
+
+		ok, _ = math.MaxUint256.TryMul(math.NewUint256(2))
+		assert.False(t, ok)
+	})
+
+	t.Run("Div and Mod", func(t *testing.T) {
+		ok, result := math.MaxUint256.TryDiv(maxUint64)
+		assert.True(t, ok)
+		ok, product := result.TryMul(maxUint64)
+		assert.True(t, ok)
+		ok, remainder := math.MaxUint256.TryMod(maxUint64)
+		assert.True(t, ok)
+		ok, sum := product.TryAdd(remainder)
+		assert.True(t, ok)
+		assert.Equal(t, math.MaxUint256, sum)
+
+		ok, _ = one.TryDiv(math.Uint256{})
+		assert.False(t, ok)
+		ok, _ = one.TryMod(math.Uint256{})
+		assert.False(t, ok)
+	})
+
+	t.Run("Cmp", func(t *testing.T) {
+		assert.Equal(t, -1, maxUint64.Cmp(math.MaxUint256))
+		assert.Equal(t, 1, math.MaxUint256.Cmp(maxUint64))
+		assert.Equal(t, 0, one.Cmp(math.NewUint256(1)))
+		assert.True(t, maxUint64.IsUint64())
+		assert.False(t, math.MaxUint256.IsUint64())
+	})
+}
+
+func TestUint256JSON(t *testing.T) {
+	bytes, err := json.Marshal(math.MaxUint256)
+	assert.NoError(t, err)
+	assert.Equal(t, `"`+maxUint256+`"`, string(bytes))
+
+	var x math.Uint256
+	assert.NoError(t, json.Unmarshal(bytes, &x))
+	assert.Equal(t, math.MaxUint256, x)
+
+	// numbers encoded by uint64 fields
+	assert.NoError(t, json.Unmarshal([]byte("18446744073709551615"), &x))
+	assert.Equal(t, math.NewUint256(18446744073709551615), x)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`"-1"`), &x), math.ErrInvalidUint256)
+}
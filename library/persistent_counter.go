@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package library
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	safemath "github.com/bestchains/bestchains-contracts/library/math"
+)
+
+var (
+	// ErrInvalidShards is returned when a sharded counter has no shard.
+	ErrInvalidShards = errors.New("invalid number of shards")
+)
+
+// CounterStore reads and writes the values of persistent counters,
+// which is satisfied by `shim.ChaincodeStubInterface`
+type CounterStore interface {
+	GetTxID() string
+	GetState(key string) ([]byte, error)
+	PutState(key string, value []byte) error
+	CreateCompositeKey(objectType string, attributes []string) (string, error)
+}
+
+// PersistentCounter is a counter kept in the world state,which reads and writes through its store.
+// Without attributes,the object type is the key of the counter,otherwise it is the composite key of the object type and attributes.
+//
+// A sharded counter spreads its value over sub-keys and each transaction increments only one of them,
+// so concurrent increments do not conflict. Reading its current value reads all the sub-keys,
+// so it should not be read by the transactions which increment it.
+type PersistentCounter struct {
+	store      CounterStore
+	objectType string
+	attributes []string
+
+	// shards is the number of sub-keys,0 if the counter is not sharded
+	shards uint64
+}
+
+// NewPersistentCounter creates a counter bound to the key of objectType and attributes
+func NewPersistentCounter(store CounterStore, objectType string, attributes ...string) *PersistentCounter {
+	return &PersistentCounter{
+		store:      store,
+		objectType: objectType,
+		attributes: attributes,
+	}
+}
+
+// NewShardedCounter creates a counter which spreads its value over shards sub-keys of objectType and attributes
+func NewShardedCounter(store CounterStore, shards uint64, objectType string, attributes ...string) (*PersistentCounter, error) {
+	if shards == 0 {
+		return nil, ErrInvalidShards
+	}
+	return &PersistentCounter{
+		store:      store,
+		objectType: objectType,
+		attributes: attributes,
+		shards:     shards,
+	}, nil
+}
+
+// Current returns the current value of the counter,which is the sum of all shards if sharded
+func (counter *PersistentCounter) Current() (uint64, error) {
+	if counter == nil {
+		return 0, ErrNilCounter
+	}
+	var total uint64
+	for shard := uint64(0); shard < counter.numShards(); shard++ {
+		value, err := counter.get(shard)
+		if err != nil {
+			return 0, err
+		}
+		succ, sum := safemath.TryAdd(total, value)
+		if !succ {
+			return 0, safemath.ErrMathOpOverflowed
+		}
+		total = sum
+	}
+	return total, nil
+}
+
+// Increment adds the specified offset to the counter.
+// A sharded counter adds it to the shard picked by the transaction ID.
+func (counter *PersistentCounter) Increment(offset uint64) error {
+	if counter == nil {
+		return ErrNilCounter
+	}
+	shard := counter.pickShard()
+	value, err := counter.get(shard)
+	if err != nil {
+		return err
+	}
+	succ, value := safemath.TryAdd(value, offset)
+	if !succ {
+		return safemath.ErrMathOpOverflowed
+	}
+	return counter.put(shard, value)
+}
+
+// Decrement subtracts the specified offset from the counter.
+// A sharded counter subtracts it from the shard picked by the transaction ID first and then the others in order.
+func (counter *PersistentCounter) Decrement(offset uint64) error {
+	if counter == nil {
+		return ErrNilCounter
+	}
+	shards := counter.numShards()
+	values := make([]uint64, shards)
+	var total uint64
+	for shard := range values {
+		value, err := counter.get(uint64(shard))
+		if err != nil {
+			return err
+		}
+		values[shard] = value
+		// The sum of shards never overflows as it is only incremented with checks
+		total += value
+	}
+	if offset > total {
+		return safemath.ErrMathOpOverflowed
+	}
+
+	start := counter.pickShard()
+	for i := uint64(0); i < shards && offset > 0; i++ {
+		shard := (start + i) % shards
+		if values[shard] == 0 {
+			continue
+		}
+		sub := offset
+		if sub > values[shard] {
+			sub = values[shard]
+		}
+		if err := counter.put(shard, values[shard]-sub); err != nil {
+			return err
+		}
+		offset -= sub
+	}
+	return nil
+}
+
+// Reset sets the counter to zero
+func (counter *PersistentCounter) Reset() error {
+	if counter == nil {
+		return ErrNilCounter
+	}
+	for shard := uint64(0); shard < counter.numShards(); shard++ {
+		if err := counter.put(shard, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (counter *PersistentCounter) numShards() uint64 {
+	if counter.shards == 0 {
+		return 1
+	}
+	return counter.shards
+}
+
+// pickShard picks the shard of this transaction by its ID,which is the same on every endorser
+func (counter *PersistentCounter) pickShard() uint64 {
+	if counter.shards <= 1 {
+		return 0
+	}
+	hashed := sha256.Sum256([]byte(counter.store.GetTxID()))
+	return binary.BigEndian.Uint64(hashed[:8]) % counter.shards
+}
+
+// key returns the key of the shard
+func (counter *PersistentCounter) key(shard uint64) (string, error) {
+	attributes := counter.attributes
+	if counter.shards != 0 {
+		attributes = append(append([]string{}, attributes...), strconv.FormatUint(shard, 10))
+	}
+	if len(attributes) == 0 {
+		return counter.objectType, nil
+	}
+	key, err := counter.store.CreateCompositeKey(counter.objectType, attributes)
+	if err != nil {
+		return "", errors.Wrap(ErrInvalidCompositeKey, err.Error())
+	}
+	return key, nil
+}
+
+func (counter *PersistentCounter) get(shard uint64) (uint64, error) {
+	key, err := counter.key(shard)
+	if err != nil {
+		return 0, err
+	}
+	value, err := counter.store.GetState(key)
+	if err != nil {
+		return 0, err
+	}
+	return BytesToUint64(value)
+}
+
+func (counter *PersistentCounter) put(shard uint64, value uint64) error {
+	key, err := counter.key(shard)
+	if err != nil {
+		return err
+	}
+	return counter.store.PutState(key, []byte(Uint64ToString(value)))
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package library_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore keeps states in memory
+type memoryStore struct {
+	txID   string
+	states map[string][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{txID: "tx", states: make(map[string][]byte)}
+}
+
+func (store *memoryStore) GetTxID() string {
+	return store.txID
+}
+
+func (store *memoryStore) GetState(key string) ([]byte, error) {
+	return store.states[key], nil
+}
+
+func (store *memoryStore) PutState(key string, value []byte) error {
+	store.states[key] = value
+	return nil
+}
+
+func (store *memoryStore) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return "\x00" + objectType + "\x00" + strings.Join(attributes, "\x00") + "\x00", nil
+}
+
+func TestPersistentCounter(t *testing.T) {
+	t.Run("Key", func(t *testing.T) {
+		store := newMemoryStore()
+		assert.NoError(t, library.NewPersistentCounter(store, "index").Increment(1))
+		assert.NoError(t, library.NewPersistentCounter(store, "nonce~account", "0x1").Increment(2))
+		assert.Equal(t, []byte("1"), store.states["index"])
+		assert.Equal(t, []byte("2"), store.states["\x00nonce~account\x000x1\x00"])
+	})
+
+	t.Run("Increment and Decrement", func(t *testing.T) {
+		counter := library.NewPersistentCounter(newMemoryStore(), "index")
+		current, err := counter.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), current)
+
+		assert.NoError(t, counter.Increment(10))
+		assert.NoError(t, counter.Decrement(3))
+		current, err = counter.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(7), current)
+
+		assert.ErrorIs(t, counter.Decrement(8), math.ErrMathOpOverflowed)
+		assert.NoError(t, counter.Increment(18446744073709551615-7))
+		assert.ErrorIs(t, counter.Increment(1), math.ErrMathOpOverflowed)
+
+		assert.NoError(t, counter.Reset())
+		current, err = counter.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), current)
+	})
+
+	t.Run("Sharded", func(t *testing.T) {
+		_, err := library.NewShardedCounter(newMemoryStore(), 0, "views")
+		assert.ErrorIs(t, err, library.ErrInvalidShards)
+
+		store := newMemoryStore()
+		counter, err := library.NewShardedCounter(store, 4, "views")
+		assert.NoError(t, err)
+		for i := 0; i < 20; i++ {
+			store.txID = fmt.Sprintf("tx%d", i)
+			assert.NoError(t, counter.Increment(1))
+		}
+		current, err := counter.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(20), current)
+		// increments are spread over the shards
+		assert.Len(t, store.states, 4)
+
+		assert.NoError(t, counter.Decrement(15))
+		current, err = counter.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(5), current)
+		assert.ErrorIs(t, counter.Decrement(6), math.ErrMathOpOverflowed)
+
+		assert.NoError(t, counter.Reset())
+		current, err = counter.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), current)
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		counter := (*library.PersistentCounter)(nil)
+		_, err := counter.Current()
+		assert.ErrorIs(t, err, library.ErrNilCounter)
+		assert.ErrorIs(t, counter.Increment(1), library.ErrNilCounter)
+	})
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"sync"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidKey is returned when a key does not belong to the algorithm.
+	ErrInvalidKey = errors.New("invalid key for algorithm")
+)
+
+// KeyAlgorithm signs and verifies messages with one kind of key.
+// Each algorithm decides how its public keys are encoded in `Message.PublicKey`
+// and how an address is derived from them.
+type KeyAlgorithm interface {
+	// ParsePublicKey parses the raw public key carried by a message
+	ParsePublicKey(raw []byte) (crypto.PublicKey, error)
+	// MarshalPublicKey marshals the public key to be carried by a message
+	MarshalPublicKey(pub crypto.PublicKey) ([]byte, error)
+	// Address derives the account address of the public key
+	Address(pub crypto.PublicKey) (library.Address, error)
+	// Public returns the public key of the private key
+	Public(priv crypto.PrivateKey) (crypto.PublicKey, error)
+	// Sign signs the digest with the private key
+	Sign(priv crypto.PrivateKey, digest []byte) ([]byte, error)
+	// Verify verifies the signature of the digest against the public key
+	Verify(pub crypto.PublicKey, digest []byte, signature []byte) bool
+}
+
+// KeyRecoverer is implemented by algorithms which are able to recover the public key from a signature,
+// so `Message.PublicKey` can be omitted.
+type KeyRecoverer interface {
+	// RecoverPublicKey recovers the public key which signs the digest
+	RecoverPublicKey(digest []byte, signature []byte) (crypto.PublicKey, error)
+}
+
+var (
+	algorithmsLock sync.RWMutex
+	algorithms     = map[SupportedAlgorithm]KeyAlgorithm{
+		ECDSA:     ecdsaAlgorithm{},
+		ED25519:   ed25519Algorithm{},
+		SECP256K1: secp256k1Algorithm{},
+	}
+)
+
+// RegisterAlgorithm registers a KeyAlgorithm under name,which replaces the algorithm registered before.
+func RegisterAlgorithm(name SupportedAlgorithm, algorithm KeyAlgorithm) {
+	algorithmsLock.Lock()
+	defer algorithmsLock.Unlock()
+	algorithms[name] = algorithm
+}
+
+// GetAlgorithm returns the KeyAlgorithm registered under name
+func GetAlgorithm(name SupportedAlgorithm) (KeyAlgorithm, error) {
+	algorithmsLock.RLock()
+	defer algorithmsLock.RUnlock()
+	algorithm, ok := algorithms[name]
+	if !ok {
+		return nil, errors.Wrap(ErrAlgorithmNotSupported, string(name))
+	}
+	return algorithm, nil
+}
+
+// AlgorithmOf returns the builtin algorithm of a private key
+func AlgorithmOf(priv crypto.PrivateKey) SupportedAlgorithm {
+	switch priv.(type) {
+	case ed25519.PrivateKey, *ed25519.PrivateKey:
+		return ED25519
+	case *secp256k1.PrivateKey:
+		return SECP256K1
+	default:
+		return ECDSA
+	}
+}
+
+// ecdsaAlgorithm signs with ECDSA keys on NIST curves(P-256,P-384 and P-521)
+// and carries public keys in PKIX form
+type ecdsaAlgorithm struct{}
+
+func (ecdsaAlgorithm) ParsePublicKey(raw []byte) (crypto.PublicKey, error) {
+	pub, err := x509.ParsePKIXPublicKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := pub.(*ecdsa.PublicKey); !ok {
+		return nil, ErrInvalidKey
+	}
+	return pub, nil
+}
+
+func (ecdsaAlgorithm) MarshalPublicKey(pub crypto.PublicKey) ([]byte, error) {
+	if _, ok := pub.(*ecdsa.PublicKey); !ok {
+		return nil, ErrInvalidKey
+	}
+	return x509.MarshalPKIXPublicKey(pub)
+}
+
+func (ecdsaAlgorithm) Address(pub crypto.PublicKey) (library.Address, error) {
+	var addr = new(library.Address)
+	if err := addr.FromPublicKey(pub); err != nil {
+		return library.ZeroAddress, err
+	}
+	return *addr, nil
+}
+
+func (ecdsaAlgorithm) Public(priv crypto.PrivateKey) (crypto.PublicKey, error) {
+	privKey, ok := priv.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return &privKey.PublicKey, nil
+}
+
+func (ecdsaAlgorithm) Sign(priv crypto.PrivateKey, digest []byte) ([]byte, error) {
+	privKey, ok := priv.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return ecdsa.SignASN1(rand.Reader, privKey, digest)
+}
+
+func (ecdsaAlgorithm) Verify(pub crypto.PublicKey, digest []byte, signature []byte) bool {
+	pubKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return false
+	}
+	return ecdsa.VerifyASN1(pubKey, digest, signature)
+}
+
+// ed25519Algorithm signs with Ed25519 keys and carries public keys in PKIX form
+type ed25519Algorithm struct{}
+
+func (ed25519Algorithm) ParsePublicKey(raw []byte) (crypto.PublicKey, error) {
+	pub, err := x509.ParsePKIXPublicKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := pub.(ed25519.PublicKey); !ok {
+		return nil, ErrInvalidKey
+	}
+	return pub, nil
+}
+
+func (ed25519Algorithm) MarshalPublicKey(pub crypto.PublicKey) ([]byte, error) {
+	if _, ok := pub.(ed25519.PublicKey); !ok {
+		return nil, ErrInvalidKey
+	}
+	return x509.MarshalPKIXPublicKey(pub)
+}
+
+func (ed25519Algorithm) Address(pub crypto.PublicKey) (library.Address, error) {
+	var addr = new(library.Address)
+	if err := addr.FromPublicKey(pub); err != nil {
+		return library.ZeroAddress, err
+	}
+	return *addr, nil
+}
+
+func (ed25519Algorithm) Public(priv crypto.PrivateKey) (crypto.PublicKey, error) {
+	switch privKey := priv.(type) {
+	case ed25519.PrivateKey:
+		return privKey.Public(), nil
+	case *ed25519.PrivateKey:
+		return privKey.Public(), nil
+	default:
+		return nil, ErrInvalidKey
+	}
+}
+
+func (ed25519Algorithm) Sign(priv crypto.PrivateKey, digest []byte) ([]byte, error) {
+	switch privKey := priv.(type) {
+	case ed25519.PrivateKey:
+		return ed25519.Sign(privKey, digest), nil
+	case *ed25519.PrivateKey:
+		return ed25519.Sign(*privKey, digest), nil
+	default:
+		return nil, ErrInvalidKey
+	}
+}
+
+func (ed25519Algorithm) Verify(pub crypto.PublicKey, digest []byte, signature []byte) bool {
+	pubKey, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return false
+	}
+	return ed25519.Verify(pubKey, digest, signature)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// renamedAlgorithm registers a builtin algorithm under another name
+type renamedAlgorithm struct {
+	protocol.KeyAlgorithm
+}
+
+func TestAlgorithm(t *testing.T) {
+	t.Run("Builtin algorithms", func(t *testing.T) {
+		for _, name := range []protocol.SupportedAlgorithm{protocol.ECDSA, protocol.ED25519} {
+			_, err := protocol.GetAlgorithm(name)
+			assert.NoError(t, err)
+		}
+		_, err := protocol.GetAlgorithm("RSA")
+		assert.ErrorIs(t, err, protocol.ErrAlgorithmNotSupported)
+	})
+
+	t.Run("RegisterAlgorithm", func(t *testing.T) {
+		builtin, err := protocol.GetAlgorithm(protocol.ED25519)
+		assert.NoError(t, err)
+		protocol.RegisterAlgorithm("EDDSA", renamedAlgorithm{KeyAlgorithm: builtin})
+
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+		domain := protocol.NewDomain("channel", "chaincode", "Function")
+		msg := &protocol.Message{Nonce: 1, Algorithm: "EDDSA"}
+		assert.NoError(t, msg.GenerateSignature(domain, privateKey, "argument1"))
+		_, err = msg.VerifyAgainstArgs(domain, "argument1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Keys of another algorithm", func(t *testing.T) {
+		ecdsaAlgorithm, err := protocol.GetAlgorithm(protocol.ECDSA)
+		assert.NoError(t, err)
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+
+		_, err = ecdsaAlgorithm.Sign(privateKey, []byte("digest"))
+		assert.ErrorIs(t, err, protocol.ErrInvalidKey)
+		_, err = ecdsaAlgorithm.MarshalPublicKey(privateKey.Public())
+		assert.ErrorIs(t, err, protocol.ErrInvalidKey)
+		assert.False(t, ecdsaAlgorithm.Verify(privateKey.Public(), []byte("digest"), []byte("signature")))
+	})
+}
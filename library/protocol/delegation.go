@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol
+
+import (
+	"github.com/bestchains/bestchains-contracts/library/math"
+)
+
+// Delegation authorizes a session key(Delegate) to send messages on behalf of Delegator.
+// A message signed by the delegate with `Delegator` set is sent by the delegator.
+type Delegation struct {
+	Delegator string `json:"delegator"`
+	Delegate  string `json:"delegate"`
+	// Functions are the functions the delegate is allowed to call
+	Functions []string `json:"functions"`
+	// SpendingCap is the total amount the delegate is allowed to spend of each asset.
+	// It is encoded as a decimal string,while a cap stored as a JSON number still decodes.
+	SpendingCap math.Uint256 `json:"spendingCap"`
+	// Spending is the amount the delegate has spent of each asset.
+	// It replaces the former `spent` which was shared by all assets and is ignored now.
+	Spending map[string]math.Uint256 `json:"spending,omitempty"`
+	// ExpiresAt is the unix timestamp(in seconds) after which the delegation is expired
+	ExpiresAt int64 `json:"expiresAt"`
+}
+
+// Allows tells whether the delegate is allowed to call function,
+// which can be prefixed with its contract namespace like `contract:function`.
+// An allowed function without contract namespace is allowed in any contract.
+func (delegation *Delegation) Allows(function string) bool {
+	contract, fn := SplitFunction(function)
+	for _, allowed := range delegation.Functions {
+		allowedContract, allowedFn := SplitFunction(allowed)
+		if allowedFn == fn && (allowedContract == "" || allowedContract == contract) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired tells whether the delegation is expired at timestamp
+func (delegation *Delegation) Expired(timestamp int64) bool {
+	return timestamp > delegation.ExpiresAt
+}
+
+// Spent returns the amount the delegate has spent of asset
+func (delegation *Delegation) Spent(asset string) math.Uint256 {
+	return delegation.Spending[asset]
+}
+
+// Remaining returns the amount the delegate is still allowed to spend of asset
+func (delegation *Delegation) Remaining(asset string) math.Uint256 {
+	ok, remaining := delegation.SpendingCap.TrySub(delegation.Spent(asset))
+	if !ok {
+		return math.NewUint256(0)
+	}
+	return remaining
+}
+
+// Spend adds amount to the spent amount of asset and tells whether it is within the spending cap
+func (delegation *Delegation) Spend(asset string, amount math.Uint256) bool {
+	if amount.Cmp(delegation.Remaining(asset)) > 0 {
+		return false
+	}
+	ok, spent := delegation.Spent(asset).TryAdd(amount)
+	if !ok {
+		return false
+	}
+	if delegation.Spending == nil {
+		delegation.Spending = make(map[string]math.Uint256)
+	}
+	delegation.Spending[asset] = spent
+	return true
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelegation(t *testing.T) {
+	delegation := &protocol.Delegation{
+		Functions:   []string{"transfer", "org.bestchains.com.ERC20Contract:Approve"},
+		SpendingCap: math.NewUint256(10),
+		ExpiresAt:   100,
+	}
+
+	assert.True(t, delegation.Allows("Transfer"))
+	assert.True(t, delegation.Allows("org.bestchains.com.DepositoryContract:transfer"))
+	assert.True(t, delegation.Allows("org.bestchains.com.ERC20Contract:approve"))
+	assert.False(t, delegation.Allows("Approve"))
+	assert.False(t, delegation.Allows("org.bestchains.com.ERC1155Contract:Approve"))
+	assert.False(t, delegation.Allows("Burn"))
+
+	assert.False(t, delegation.Expired(100))
+	assert.True(t, delegation.Expired(101))
+
+	assert.True(t, delegation.Spend("erc20", math.NewUint256(4)))
+	assert.Equal(t, math.NewUint256(6), delegation.Remaining("erc20"))
+	assert.False(t, delegation.Spend("erc20", math.NewUint256(7)))
+	assert.Equal(t, math.NewUint256(4), delegation.Spent("erc20"))
+
+	// each asset has its own spending
+	assert.Equal(t, math.NewUint256(10), delegation.Remaining("erc1155/1"))
+	assert.True(t, delegation.Spend("erc1155/1", math.NewUint256(10)))
+	assert.Equal(t, math.NewUint256(0), delegation.Remaining("erc1155/1"))
+
+	// a lowered cap leaves nothing to spend
+	delegation.SpendingCap = math.NewUint256(2)
+	assert.Equal(t, math.NewUint256(0), delegation.Remaining("erc20"))
+}
+
+func TestDelegationJSON(t *testing.T) {
+	// a delegation stored with uint64 caps,whose spent amount was shared by all assets
+	stored := &protocol.Delegation{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"spendingCap":18446744073709551615,"spent":5,"expiresAt":100}`), stored))
+	assert.Equal(t, "18446744073709551615", stored.Remaining("erc20").String())
+	assert.True(t, stored.Spend("erc20", math.NewUint256(5)))
+
+	bytes, err := json.Marshal(stored)
+	assert.NoError(t, err)
+	decoded := &protocol.Delegation{}
+	assert.NoError(t, json.Unmarshal(bytes, decoded))
+	assert.Equal(t, "18446744073709551610", decoded.Remaining("erc20").String())
+
+	// a cap beyond uint64
+	bigCap, err := math.ParseUint256("100000000000000000000000")
+	assert.NoError(t, err)
+	bytes, err = json.Marshal(&protocol.Delegation{SpendingCap: bigCap})
+	assert.NoError(t, err)
+	decoded = &protocol.Delegation{}
+	assert.NoError(t, json.Unmarshal(bytes, decoded))
+	assert.Equal(t, bigCap, decoded.SpendingCap)
+}
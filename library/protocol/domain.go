@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrInvalidDomain is returned when the signing domain can not be resolved from the transaction.
+	ErrInvalidDomain = errors.New("invalid signing domain")
+)
+
+const (
+	// SchemeVersion is the version of the typed signing scheme
+	SchemeVersion uint8 = 1
+
+	// typedDataPrefix prevents a typed payload from being a valid encoding of anything else
+	typedDataPrefix = "\x19\x01"
+)
+
+// Domain separates signed messages by where they are allowed to be executed,
+// just like the domain separator of EIP-712
+type Domain struct {
+	ChannelID string `json:"channelId"`
+	Chaincode string `json:"chaincode"`
+	// Contract is the registered name of the contract the message is for,
+	// so a message can not be replayed on another contract with the same function
+	Contract string `json:"contract"`
+	Function string `json:"function"`
+	Version  uint8  `json:"version"`
+}
+
+// NewDomain creates a Domain with the current scheme version.
+// `function` is the invoked function which must be prefixed with the contract name like `contract:function`
+// unless the contract has no name
+func NewDomain(channelID string, chaincode string, function string) Domain {
+	contract, fn := SplitFunction(function)
+	return Domain{
+		ChannelID: channelID,
+		Chaincode: chaincode,
+		Contract:  contract,
+		Function:  fn,
+		Version:   SchemeVersion,
+	}
+}
+
+// SplitFunction splits an invoked function into its contract namespace and function name
+// in the same way contractapi does,so `transfer` and `Transfer` are the same function.
+func SplitFunction(function string) (string, string) {
+	var contract string
+	if index := strings.LastIndex(function, ":"); index != -1 {
+		contract = function[:index]
+		function = function[index+1:]
+	}
+	if function != "" {
+		runes := []rune(function)
+		runes[0] = unicode.ToUpper(runes[0])
+		function = string(runes)
+	}
+	return contract, function
+}
+
+// Separator returns the hash of the length-prefixed domain fields
+func (domain Domain) Separator() []byte {
+	encoded := []byte{domain.Version}
+	for _, field := range []string{domain.ChannelID, domain.Chaincode, domain.Contract, domain.Function} {
+		encoded = appendBytes(encoded, []byte(field))
+	}
+	hashed := sha3.Sum256(encoded)
+	return hashed[:]
+}
+
+// appendUint64 appends the big-endian encoding of ui64
+func appendUint64(dst []byte, ui64 uint64) []byte {
+	return binary.BigEndian.AppendUint64(dst, ui64)
+}
+
+// appendBytes appends the length-prefixed encoding of src
+func appendBytes(dst []byte, src []byte) []byte {
+	dst = appendUint64(dst, uint64(len(src)))
+	return append(dst, src...)
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol_test
+
+import (
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFunction(t *testing.T) {
+	contract, function := protocol.SplitFunction("org.bestchains.com.ERC20Contract:transfer")
+	assert.Equal(t, "org.bestchains.com.ERC20Contract", contract)
+	assert.Equal(t, "Transfer", function)
+
+	contract, function = protocol.SplitFunction("Transfer")
+	assert.Equal(t, "", contract)
+	assert.Equal(t, "Transfer", function)
+}
+
+func TestDomainSeparator(t *testing.T) {
+	domain := protocol.NewDomain("channel", "chaincode", "contract:Function")
+	assert.Len(t, domain.Separator(), 32)
+	assert.Equal(t, domain.Separator(), protocol.NewDomain("channel", "chaincode", "contract:function").Separator())
+	assert.NotEqual(t, domain.Separator(), protocol.NewDomain("channe", "lchaincode", "contract:Function").Separator())
+}
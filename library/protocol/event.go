@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidEventEnvelope is returned when an event envelope can not be decoded.
+	ErrInvalidEventEnvelope = errors.New("invalid event envelope")
+)
+
+const (
+	// EventEnvelopeVersion is the version of the event envelope
+	EventEnvelopeVersion uint8 = 1
+
+	// EventEnvelopeName is the name of the chaincode event which carries the event envelope
+	EventEnvelopeName = "bestchains.events"
+)
+
+// Event is an event emitted by `EmitEvent`
+type Event struct {
+	Name    string          `json:"name"`
+	Payload json.RawMessage `json:"payload"`
+	TxID    string          `json:"txId"`
+	// Timestamp is the transaction timestamp in unix seconds
+	Timestamp int64 `json:"timestamp"`
+}
+
+// EventEnvelope carries all events of a transaction,
+// because fabric only keeps the last chaincode event set by a transaction.
+type EventEnvelope struct {
+	Version uint8   `json:"version"`
+	Events  []Event `json:"events"`
+}
+
+// DecodeEvents splits a chaincode event back into the events emitted by the transaction.
+// A chaincode event which is not an envelope is returned as a single event with only name and payload.
+func DecodeEvents(eventName string, payload []byte) ([]Event, error) {
+	if eventName != EventEnvelopeName {
+		return []Event{{Name: eventName, Payload: payload}}, nil
+	}
+
+	envelope := new(EventEnvelope)
+	if err := json.Unmarshal(payload, envelope); err != nil {
+		return nil, errors.Wrap(ErrInvalidEventEnvelope, err.Error())
+	}
+	if envelope.Version != EventEnvelopeVersion {
+		return nil, errors.Wrapf(ErrInvalidEventEnvelope, "version %d not supported", envelope.Version)
+	}
+	return envelope.Events, nil
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeEvents(t *testing.T) {
+	events, err := protocol.DecodeEvents("Transfer", []byte(`{"value":1}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []protocol.Event{{Name: "Transfer", Payload: json.RawMessage(`{"value":1}`)}}, events)
+
+	_, err = protocol.DecodeEvents(protocol.EventEnvelopeName, []byte(`{"version":2,"events":[]}`))
+	assert.ErrorIs(t, err, protocol.ErrInvalidEventEnvelope)
+	_, err = protocol.DecodeEvents(protocol.EventEnvelopeName, []byte(`[]`))
+	assert.ErrorIs(t, err, protocol.ErrInvalidEventEnvelope)
+}
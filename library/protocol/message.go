@@ -0,0 +1,371 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package protocol defines what clients and contracts exchange: signed messages,
+// the domains and key algorithms they are signed with and event envelopes.
+// It does not depend on the chaincode shim,so clients built with fabric-gateway can import it.
+package protocol
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrNotMessage is returned when the provided data is not a message.
+	ErrNotMessage = errors.New("not a message")
+
+	// ErrInvalidMessage is returned when the provided message is invalid.
+	ErrInvalidMessage = errors.New("invalid message")
+
+	// ErrInvalidMessageSender is returned when the message sender is invalid.
+	ErrInvalidMessageSender = errors.New("invalid message sender")
+
+	// ErrAlgorithmNotSupported is returned when an unsupported algorithm is used for message signing.
+	ErrAlgorithmNotSupported = errors.New("algorithm not supported yet")
+
+	// ErrInvalidSignature is returned when the message signature is invalid.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrMessageVersionNotSupported is returned when the message version is unknown or not accepted.
+	ErrMessageVersionNotSupported = errors.New("message version not supported")
+)
+
+// SupportedAlgorithm represents a supported message signing algorithm.
+type SupportedAlgorithm string
+
+const (
+	// ECDSA is a supported message signing algorithm on NIST curves(P-256,P-384 and P-521).
+	ECDSA SupportedAlgorithm = "ECDSA"
+	// ED25519 is a supported message signing algorithm.
+	ED25519 SupportedAlgorithm = "ED25519"
+	// SECP256K1 is a supported message signing algorithm which is compatible with ethereum wallets(`personal_sign`).
+	SECP256K1 SupportedAlgorithm = "SECP256K1"
+	// WEBAUTHN is a supported message signing algorithm for P-256 passkeys,which must be registered with NewWebAuthnAlgorithm.
+	WEBAUTHN SupportedAlgorithm = "WEBAUTHN"
+)
+
+// MessageVersion represents the version of the digest a message is signed with.
+type MessageVersion uint8
+
+const (
+	// MessageV1 is the legacy version which signs `LegacyPayload` with `GenerateLegacyHash`,
+	// just like clients did before messages were versioned.
+	// Messages without a version are treated as MessageV1.
+	//
+	// Deprecated: MessageV1 is neither bound to a signing domain nor unambiguous,
+	// it is only verified during the migration window.
+	MessageV1 MessageVersion = 1
+	// MessageV2 signs the SHA-512 digest of the typed payload.
+	MessageV2 MessageVersion = 2
+
+	// LatestMessageVersion is the version used to sign new messages.
+	LatestMessageVersion = MessageV2
+)
+
+type Message struct {
+	Nonce     uint64         `json:"nonce"`
+	PublicKey string         `json:"publicKey"`
+	Signature string         `json:"signature"`
+	Version   MessageVersion `json:"version,omitempty"`
+	// Algorithm is the key algorithm the message is signed with,ECDSA if it is not set
+	Algorithm SupportedAlgorithm `json:"algorithm,omitempty"`
+	// ValidAfter is the unix timestamp(in seconds) from which the message is valid,no lower bound if it is not set
+	ValidAfter int64 `json:"validAfter,omitempty"`
+	// ValidUntil is the unix timestamp(in seconds) until which the message is valid,no upper bound if it is not set
+	ValidUntil int64 `json:"validUntil,omitempty"`
+	// Delegator is the address which delegates the signer(a session key) to send the message,
+	// empty if the message is sent by the signer itself
+	Delegator string `json:"delegator,omitempty"`
+}
+
+// GetAlgorithm returns the key algorithm of the message, ECDSA if it is not set.
+func (msg *Message) GetAlgorithm() SupportedAlgorithm {
+	if msg.Algorithm == "" {
+		return ECDSA
+	}
+	return msg.Algorithm
+}
+
+// GetVersion returns the version of the message, MessageV1 if it is not set.
+func (msg *Message) GetVersion() MessageVersion {
+	if msg.Version == 0 {
+		return MessageV1
+	}
+	return msg.Version
+}
+
+// Marshal returns the JSON encoding of a Message struct.
+// If the input Message pointer is nil, a new Message is created.
+func (msg *Message) Marshal() ([]byte, error) {
+	if msg == nil {
+		msg = new(Message)
+	}
+	return json.Marshal(msg)
+}
+
+// Unmarshal unmarshals a byte slice into a Message struct
+func (msg *Message) Unmarshal(bytes []byte) error {
+	var err error
+
+	// If the Message is nil, create a new one
+	if msg == nil {
+		msg = new(Message)
+	}
+
+	// Unmarshal the bytes into the Message struct
+	if err = json.Unmarshal(bytes, msg); err != nil {
+		// If there is an error, wrap it with ErrNotMessage and return it
+		return errors.Wrap(ErrNotMessage, err.Error())
+	}
+
+	// Return nil to indicate success
+	return nil
+}
+
+// Base64EncodedStr returns the base64-encoded string representation of the Message struct.
+func (msg *Message) Base64EncodedStr() (string, error) {
+	// Marshal the Message struct into bytes.
+	bytes, err := msg.Marshal()
+	if err != nil {
+		// If there was an error during marshaling, return an error with a wrapped message.
+		return "", errors.Wrap(ErrInvalidMessage, err.Error())
+	}
+
+	// Return the base64-encoded string of the marshalled bytes.
+	return base64.StdEncoding.EncodeToString(bytes), nil
+}
+
+// FromBase64EncodedStr decodes a base64-encoded string and unmarshals it into a Message struct.
+// It returns an error if the string could not be decoded or if the unmarshaling fails.
+func (msg *Message) FromBase64EncodedStr(str string) error {
+	// Decode the string using base64.StdEncoding.DecodeString
+	bytes, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		// Return an error if the decoding fails
+		return errors.Wrap(ErrInvalidMessage, err.Error())
+	}
+
+	// Unmarshal the decoded bytes into a Message struct using msg.Unmarshal
+	return msg.Unmarshal(bytes)
+}
+
+// VerifyAgainstArgs verifies the message against the given domain and arguments and returns the sender's address.
+// If the message is invalid, it returns an error.
+func (msg *Message) VerifyAgainstArgs(domain Domain, args ...string) (library.Address, error) {
+	// Get the key algorithm of the message.
+	algorithm, err := GetAlgorithm(msg.GetAlgorithm())
+	if err != nil {
+		return library.ZeroAddress, errors.Wrap(ErrInvalidMessage, err.Error())
+	}
+
+	// Decode the signature from base64.
+	rawSignature, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return library.ZeroAddress, err
+	}
+
+	// Generate the hash of the payload.
+	hashedPayload, err := msg.Digest(domain, args...)
+	if err != nil {
+		return library.ZeroAddress, errors.Wrap(ErrInvalidMessage, err.Error())
+	}
+
+	// Get the public key which is either carried by the message or recovered from the signature.
+	pub, err := msg.publicKey(algorithm, hashedPayload, rawSignature)
+	if err != nil {
+		return library.ZeroAddress, errors.Wrap(ErrInvalidMessage, err.Error())
+	}
+
+	// Convert the public key to the sender's address.
+	msgSender, err := algorithm.Address(pub)
+	if err != nil {
+		return library.ZeroAddress, err
+	}
+
+	// Verify the signature against the public key and hashed payload.
+	if !algorithm.Verify(pub, hashedPayload, rawSignature) {
+		return library.ZeroAddress, errors.Wrap(ErrInvalidMessage, ErrInvalidSignature.Error())
+	}
+
+	// Return the sender's address.
+	return msgSender, nil
+}
+
+// publicKey parses the public key of the message.
+// The public key can be omitted if the algorithm is able to recover it from the signature.
+func (msg *Message) publicKey(algorithm KeyAlgorithm, digest []byte, signature []byte) (crypto.PublicKey, error) {
+	if msg.PublicKey == "" {
+		if recoverer, ok := algorithm.(KeyRecoverer); ok {
+			return recoverer.RecoverPublicKey(digest, signature)
+		}
+	}
+
+	// Decode the public key from base64.
+	rawPubKey, err := base64.StdEncoding.DecodeString(msg.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the public key.
+	return algorithm.ParsePublicKey(rawPubKey)
+}
+
+// VerifySignature verifies that message was signed by the private key corresponding to the provided public key
+func VerifySignature(pub crypto.PublicKey, message, sig []byte) bool {
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		// For ECDSA keys, verify the signature using ASN.1 encoding
+		return ecdsa.VerifyASN1(pub, message, sig)
+	case ed25519.PublicKey:
+		// For Ed25519 keys, verify the signature directly
+		return ed25519.Verify(pub, message, sig)
+	default:
+		// If the public key type is unsupported, return false
+		return false
+	}
+}
+
+// GenerateSignature generates a cryptographic signature for the message using the provided domain, private key and arguments.
+// It sets the Signature and PublicKey fields of the Message struct,the Version field to LatestMessageVersion if it is not set
+// and the Algorithm field to the algorithm of the private key if it is not set.
+func (msg *Message) GenerateSignature(domain Domain, privkey crypto.PrivateKey, args ...string) error {
+	// Sign with the latest version unless the version is set explicitly.
+	if msg.Version == 0 {
+		msg.Version = LatestMessageVersion
+	}
+
+	// Sign with the private key's algorithm unless the algorithm is set explicitly.
+	if msg.Algorithm == "" {
+		msg.Algorithm = AlgorithmOf(privkey)
+	}
+	algorithm, err := GetAlgorithm(msg.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	// Generate the digest for the message using the provided domain and arguments.
+	digest, err := msg.Digest(domain, args...)
+	if err != nil {
+		return err
+	}
+
+	// Generate the cryptographic signature for the digest using the provided private key.
+	signature, err := algorithm.Sign(privkey, digest)
+	if err != nil {
+		return err
+	}
+
+	// Set the Signature field of the message to the base64-encoded signature.
+	msg.Signature = base64.StdEncoding.EncodeToString(signature)
+
+	// Set the PublicKey field of the message to the base64-encoded public key of the private key used to generate the signature.
+	pub, err := algorithm.Public(privkey)
+	if err != nil {
+		return err
+	}
+	pubBytes, err := algorithm.MarshalPublicKey(pub)
+	if err != nil {
+		return err
+	}
+	msg.PublicKey = base64.StdEncoding.EncodeToString(pubBytes)
+
+	// Return nil to indicate that no error occurred.
+	return nil
+}
+
+// GeneratePayload encodes the domain separator and the hash of the message nonce and the provided arguments
+// into a typed byte slice payload, which is `0x19 0x01 || domainSeparator || hashStruct(message)` just like EIP-712.
+func (msg *Message) GeneratePayload(domain Domain, args ...string) []byte {
+	// Start with the prefix and the domain separator.
+	payload := append([]byte(typedDataPrefix), domain.Separator()...)
+	// Then the hash of the message struct.
+	return append(payload, msg.hashStruct(args...)...)
+}
+
+// hashStruct returns the hash of the message nonce and the provided arguments.
+// Arguments are length-prefixed so that different argument lists never share the same encoding.
+func (msg *Message) hashStruct(args ...string) []byte {
+	// Start with the message nonce and the number of arguments.
+	encoded := appendUint64(nil, msg.Nonce)
+	encoded = appendUint64(encoded, uint64(len(args)))
+	// Append each length-prefixed argument in sequence.
+	for _, arg := range args {
+		encoded = appendBytes(encoded, []byte(arg))
+	}
+	// Append the validity window only if it is set,so messages without it keep their payload.
+	// It is always appended before the delegator so that the two never share the same encoding.
+	if msg.ValidAfter != 0 || msg.ValidUntil != 0 || msg.Delegator != "" {
+		encoded = appendUint64(encoded, uint64(msg.ValidAfter))
+		encoded = appendUint64(encoded, uint64(msg.ValidUntil))
+	}
+	if msg.Delegator != "" {
+		encoded = appendBytes(encoded, []byte(msg.Delegator))
+	}
+	hashed := sha3.Sum256(encoded)
+	return hashed[:]
+}
+
+// LegacyPayload concatenates the message nonce in decimal with the provided arguments,
+// which is the payload of MessageV1.
+//
+// Deprecated: only used to verify MessageV1 signatures during the migration window.
+func (msg *Message) LegacyPayload(args ...string) []byte {
+	payload := []byte(library.Uint64ToString(msg.Nonce))
+	for _, arg := range args {
+		payload = append(payload, []byte(arg)...)
+	}
+	return payload
+}
+
+// Digest returns the digest to be signed for the message according to its version.
+// The domain is ignored by MessageV1,which can not carry a validity window or a delegator either.
+func (msg *Message) Digest(domain Domain, args ...string) ([]byte, error) {
+	switch msg.GetVersion() {
+	case MessageV1:
+		if msg.ValidAfter != 0 || msg.ValidUntil != 0 || msg.Delegator != "" {
+			return nil, errors.Wrap(ErrMessageVersionNotSupported, "validity window and delegator require MessageV2")
+		}
+		return GenerateLegacyHash(msg.LegacyPayload(args...)), nil
+	case MessageV2:
+		return GenerateHash(msg.GeneratePayload(domain, args...)), nil
+	default:
+		return nil, ErrMessageVersionNotSupported
+	}
+}
+
+// GenerateHash returns the SHA-512 hash of the given payload.
+// The returned hash is a byte slice.
+func GenerateHash(payload []byte) []byte {
+	hashed := sha512.Sum512(payload)
+	return hashed[:]
+}
+
+// GenerateLegacyHash returns the payload appended with the SHA-512 hash of an empty input,
+// which is how MessageV1 digests are generated(`sha512.New().Sum(payload)` appends instead of hashing).
+//
+// Deprecated: only used to verify MessageV1 signatures during the migration window.
+func GenerateLegacyHash(payload []byte) []byte {
+	return sha512.New().Sum(payload[:])
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// legacyMessage is signed by the sender with args `"to","1"` before messages were versioned,
+// whose digest is `sha512.New().Sum([]byte("0to1"))`
+const (
+	legacyMessage = `{"nonce":0,"publicKey":"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEkCPEdcyQ7c1xdRq5ouyKqOQERZse24c6jFGROJt1dWIEWMFtyAVViufmilCQfskOVsGiUTxQyoMdJJ5FqKvifw==","signature":"MEQCIBbUXQjV8T7XlIr6pxyx1Omd8l9bj7LY2eA7QhpImP+oAiBBT+uMsa9hdiOMQL5Pz/ydiFgNuUkv5j8b1nBinW5sEg=="}`
+	legacySender  = "0xa8ecc0cf6a95ff5f038d0754dd1217deabde6950"
+)
+
+func TestMessage(t *testing.T) {
+	// Generate a random private key for testing
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	// Signing domain for testing
+	domain := protocol.NewDomain("channel", "chaincode", "contract:Function")
+
+	// Create a new message
+	msg := &protocol.Message{
+		Nonce:     123456,
+		PublicKey: "",
+		Signature: "",
+	}
+
+	// Test GenerateHash method
+	t.Run("GenerateHash", func(t *testing.T) {
+		expected := sha512.Sum512([]byte("payload"))
+		assert.Equal(t, expected[:], protocol.GenerateHash([]byte("payload")))
+	})
+
+	// Test Marshal method
+	t.Run("Marshal", func(t *testing.T) {
+		expectedJSON := `{"nonce":123456,"publicKey":"","signature":""}`
+		data, err := msg.Marshal()
+		assert.NoError(t, err)
+		assert.Equal(t, expectedJSON, string(data))
+	})
+
+	// Test Unmarshal method
+	t.Run("Unmarshal", func(t *testing.T) {
+		jsonData := []byte(`{"nonce":123456,"publicKey":"","signature":""}`)
+		err := msg.Unmarshal(jsonData)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(123456), msg.Nonce)
+		assert.Equal(t, "", msg.PublicKey)
+		assert.Equal(t, "", msg.Signature)
+	})
+
+	// Test Base64EncodedStr and FromBase64EncodedStr methods
+	t.Run("Base64EncodedStr and FromBase64EncodedStr", func(t *testing.T) {
+		// Encode the message to base64-encoded string
+		base64Str, err := msg.Base64EncodedStr()
+		assert.NoError(t, err)
+
+		// Create a new message and decode from the base64-encoded string
+		decodedMsg := &protocol.Message{}
+		err = decodedMsg.FromBase64EncodedStr(base64Str)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, decodedMsg)
+	})
+
+	// Test GenerateSignature and VerifyAgainstArgs methods
+	t.Run("GenerateSignature and VerifyAgainstArgs", func(t *testing.T) {
+		// Generate a signature for the message
+		err := msg.GenerateSignature(domain, privateKey, "argument1", "argument2")
+		assert.NoError(t, err)
+
+		// Verify the signature against the arguments
+		addr, err := msg.VerifyAgainstArgs(domain, "argument1", "argument2")
+		assert.NoError(t, err)
+
+		// Ensure the address is not zero
+		assert.NotEqual(t, library.ZeroAddress, addr)
+	})
+
+	// Test GenerateSignature and VerifyAgainstArgs methods with each version
+	t.Run("GenerateSignature and VerifyAgainstArgs with versions", func(t *testing.T) {
+		for _, version := range []protocol.MessageVersion{protocol.MessageV1, protocol.MessageV2} {
+			versioned := &protocol.Message{Nonce: 1, Version: version}
+			err := versioned.GenerateSignature(domain, privateKey, "argument1")
+			assert.NoError(t, err)
+
+			_, err = versioned.VerifyAgainstArgs(domain, "argument1")
+			assert.NoError(t, err)
+
+			// A signature of one version never verifies as another version
+			versioned.Version = protocol.MessageV1 + protocol.MessageV2 - version
+			_, err = versioned.VerifyAgainstArgs(domain, "argument1")
+			assert.ErrorIs(t, err, protocol.ErrInvalidMessage)
+		}
+
+		unknown := &protocol.Message{Nonce: 1, Version: protocol.LatestMessageVersion + 1}
+		assert.ErrorIs(t, unknown.GenerateSignature(domain, privateKey, "argument1"), protocol.ErrMessageVersionNotSupported)
+	})
+
+	// Test VerifyAgainstArgs method with a message signed by clients before messages were versioned
+	t.Run("VerifyAgainstArgs with a legacy message", func(t *testing.T) {
+		legacy := new(protocol.Message)
+		assert.NoError(t, legacy.Unmarshal([]byte(legacyMessage)))
+		assert.Equal(t, protocol.MessageV1, legacy.GetVersion())
+
+		// MessageV1 is not bound to a domain
+		addr, err := legacy.VerifyAgainstArgs(domain, "to", "1")
+		assert.NoError(t, err)
+		assert.Equal(t, library.Address(legacySender), addr)
+
+		_, err = legacy.VerifyAgainstArgs(domain, "to", "2")
+		assert.ErrorIs(t, err, protocol.ErrInvalidMessage)
+
+		// the validity window is not signed by MessageV1
+		legacy.ValidUntil = 1
+		_, err = legacy.Digest(domain, "to", "1")
+		assert.ErrorIs(t, err, protocol.ErrMessageVersionNotSupported)
+		_, err = legacy.VerifyAgainstArgs(domain, "to", "1")
+		assert.ErrorIs(t, err, protocol.ErrInvalidMessage)
+	})
+
+	// Test GenerateSignature and VerifyAgainstArgs methods with each key algorithm
+	t.Run("GenerateSignature and VerifyAgainstArgs with algorithms", func(t *testing.T) {
+		p384PrivateKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		assert.NoError(t, err)
+		ed25519PublicKey, ed25519PrivateKey, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+
+		for _, test := range []struct {
+			privateKey interface{}
+			publicKey  interface{}
+			algorithm  protocol.SupportedAlgorithm
+		}{
+			{privateKey: p384PrivateKey, publicKey: &p384PrivateKey.PublicKey, algorithm: protocol.ECDSA},
+			{privateKey: ed25519PrivateKey, publicKey: ed25519PublicKey, algorithm: protocol.ED25519},
+		} {
+			signed := &protocol.Message{Nonce: 1}
+			err := signed.GenerateSignature(domain, test.privateKey, "argument1")
+			assert.NoError(t, err)
+			assert.Equal(t, test.algorithm, signed.Algorithm)
+
+			addr, err := signed.VerifyAgainstArgs(domain, "argument1")
+			assert.NoError(t, err)
+			var expected = new(library.Address)
+			assert.NoError(t, expected.FromPublicKey(test.publicKey))
+			assert.Equal(t, *expected, addr)
+
+			_, err = signed.VerifyAgainstArgs(domain, "argument2")
+			assert.ErrorIs(t, err, protocol.ErrInvalidMessage)
+		}
+
+		// The public key must belong to the algorithm of the message
+		mismatched := &protocol.Message{Nonce: 1}
+		assert.NoError(t, mismatched.GenerateSignature(domain, ed25519PrivateKey, "argument1"))
+		mismatched.Algorithm = protocol.ECDSA
+		_, err = mismatched.VerifyAgainstArgs(domain, "argument1")
+		assert.ErrorIs(t, err, protocol.ErrInvalidMessage)
+
+		unknown := &protocol.Message{Nonce: 1, Algorithm: "RSA"}
+		assert.ErrorIs(t, unknown.GenerateSignature(domain, privateKey, "argument1"), protocol.ErrAlgorithmNotSupported)
+	})
+
+	// Test VerifyAgainstArgs method with invalid arguments
+	t.Run("VerifyAgainstArgs with invalid arguments", func(t *testing.T) {
+		// Create a new message with a different nonce
+		invalidMsg := &protocol.Message{
+			Nonce:     654321,
+			PublicKey: msg.PublicKey,
+			Signature: msg.Signature,
+		}
+
+		// Verify the signature against different arguments (should fail)
+		_, err := invalidMsg.VerifyAgainstArgs(domain, "argument1", "argument2")
+		assert.ErrorIs(t, err, protocol.ErrInvalidMessage)
+	})
+
+	// Test VerifyAgainstArgs method with shifted argument boundaries
+	t.Run("VerifyAgainstArgs with shifted arguments", func(t *testing.T) {
+		err := msg.GenerateSignature(domain, privateKey, "ab", "c")
+		assert.NoError(t, err)
+
+		for _, args := range [][]string{{"a", "bc"}, {"abc"}, {"ab", "c", ""}} {
+			_, err = msg.VerifyAgainstArgs(domain, args...)
+			assert.ErrorIs(t, err, protocol.ErrInvalidMessage)
+		}
+	})
+
+	// Test VerifyAgainstArgs method with another domain
+	t.Run("VerifyAgainstArgs with another domain", func(t *testing.T) {
+		err := msg.GenerateSignature(domain, privateKey, "argument1")
+		assert.NoError(t, err)
+
+		for _, other := range []protocol.Domain{
+			protocol.NewDomain("another", "chaincode", "contract:Function"),
+			protocol.NewDomain("channel", "another", "contract:Function"),
+			protocol.NewDomain("channel", "chaincode", "another:Function"),
+			protocol.NewDomain("channel", "chaincode", "contract:Another"),
+		} {
+			_, err = msg.VerifyAgainstArgs(other, "argument1")
+			assert.ErrorIs(t, err, protocol.ErrInvalidMessage)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol
+
+import (
+	"crypto"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// ethereumSignedMessagePrefix is prepended to the signed data by `personal_sign` and `eth_sign`
+	ethereumSignedMessagePrefix = "\x19Ethereum Signed Message:\n"
+
+	// recoverableSignatureLength is the length of a recoverable signature `R || S || V`
+	recoverableSignatureLength = 65
+)
+
+// EthereumMessageHash returns the hash which ethereum wallets sign for data with `personal_sign`
+//
+//	keccak256("\x19Ethereum Signed Message:\n" || len(data) || data)
+func EthereumMessageHash(data []byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write([]byte(ethereumSignedMessagePrefix + strconv.Itoa(len(data))))
+	hasher.Write(data)
+	return hasher.Sum(nil)
+}
+
+// secp256k1Algorithm signs the message digest just like ethereum wallets do with `personal_sign`.
+// Signatures are 65 bytes `R || S || V` from which the public key can be recovered,
+// and public keys are carried in the uncompressed SEC1 form.
+type secp256k1Algorithm struct{}
+
+func (secp256k1Algorithm) ParsePublicKey(raw []byte) (crypto.PublicKey, error) {
+	return secp256k1.ParsePubKey(raw)
+}
+
+func (secp256k1Algorithm) MarshalPublicKey(pub crypto.PublicKey) ([]byte, error) {
+	pubKey, ok := pub.(*secp256k1.PublicKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return pubKey.SerializeUncompressed(), nil
+}
+
+// Address derives the same address as ethereum,which is the last 20 bytes of keccak256(X || Y)
+func (secp256k1Algorithm) Address(pub crypto.PublicKey) (library.Address, error) {
+	pubKey, ok := pub.(*secp256k1.PublicKey)
+	if !ok {
+		return library.ZeroAddress, ErrInvalidKey
+	}
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(pubKey.SerializeUncompressed()[1:])
+	hashedPubKey := hasher.Sum(nil)
+	return library.Address(library.AddressPrefix + hex.EncodeToString(hashedPubKey[12:])), nil
+}
+
+func (secp256k1Algorithm) Public(priv crypto.PrivateKey) (crypto.PublicKey, error) {
+	privKey, ok := priv.(*secp256k1.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return privKey.PubKey(), nil
+}
+
+func (secp256k1Algorithm) Sign(priv crypto.PrivateKey, digest []byte) ([]byte, error) {
+	privKey, ok := priv.(*secp256k1.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	// The compact signature is `V || R || S` with V = 27 + recovery id
+	compact := ecdsa.SignCompact(privKey, EthereumMessageHash(digest), false)
+	return append(compact[1:], compact[0]), nil
+}
+
+func (algorithm secp256k1Algorithm) Verify(pub crypto.PublicKey, digest []byte, signature []byte) bool {
+	pubKey, ok := pub.(*secp256k1.PublicKey)
+	if !ok {
+		return false
+	}
+	recovered, err := algorithm.RecoverPublicKey(digest, signature)
+	if err != nil {
+		return false
+	}
+	return pubKey.IsEqual(recovered.(*secp256k1.PublicKey))
+}
+
+// RecoverPublicKey recovers the public key like ethereum's `ecrecover`.
+// V of the signature can be either 0/1 or 27/28.
+func (secp256k1Algorithm) RecoverPublicKey(digest []byte, signature []byte) (crypto.PublicKey, error) {
+	if len(signature) != recoverableSignatureLength {
+		return nil, errors.Wrapf(ErrInvalidSignature, "signature must be %d bytes", recoverableSignatureLength)
+	}
+	v := signature[recoverableSignatureLength-1]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return nil, errors.Wrap(ErrInvalidSignature, "invalid recovery id")
+	}
+	compact := append([]byte{27 + v}, signature[:recoverableSignatureLength-1]...)
+	pubKey, _, err := ecdsa.RecoverCompact(compact, EthereumMessageHash(digest))
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidSignature, err.Error())
+	}
+	return pubKey, nil
+}
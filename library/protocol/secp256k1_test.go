@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vector of `web3.eth.accounts.sign('Some data', privateKey)`
+const (
+	testEthereumPrivateKey  = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testEthereumAddress     = "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
+	testEthereumMessageHash = "1da44b586eb0729ff70a73c326926f6ed5a25f5b056e7f47fbc6e58d86871655"
+	testEthereumSignature   = "b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c"
+)
+
+func TestSecp256k1(t *testing.T) {
+	rawPrivateKey, err := hex.DecodeString(testEthereumPrivateKey)
+	assert.NoError(t, err)
+	privateKey := secp256k1.PrivKeyFromBytes(rawPrivateKey)
+
+	algorithm, err := protocol.GetAlgorithm(protocol.SECP256K1)
+	assert.NoError(t, err)
+
+	t.Run("Ethereum compatible", func(t *testing.T) {
+		assert.Equal(t, testEthereumMessageHash, hex.EncodeToString(protocol.EthereumMessageHash([]byte("Some data"))))
+
+		addr, err := algorithm.Address(privateKey.PubKey())
+		assert.NoError(t, err)
+		assert.Equal(t, library.Address(testEthereumAddress), addr)
+
+		signature, err := algorithm.Sign(privateKey, []byte("Some data"))
+		assert.NoError(t, err)
+		assert.Equal(t, testEthereumSignature, hex.EncodeToString(signature))
+		assert.True(t, algorithm.Verify(privateKey.PubKey(), []byte("Some data"), signature))
+	})
+
+	t.Run("RecoverPublicKey", func(t *testing.T) {
+		signature, err := hex.DecodeString(testEthereumSignature)
+		assert.NoError(t, err)
+		recoverer, ok := algorithm.(protocol.KeyRecoverer)
+		assert.True(t, ok)
+
+		pub, err := recoverer.RecoverPublicKey([]byte("Some data"), signature)
+		assert.NoError(t, err)
+		assert.True(t, privateKey.PubKey().IsEqual(pub.(*secp256k1.PublicKey)))
+
+		// V can also be 0/1
+		signature[64] -= 27
+		pub, err = recoverer.RecoverPublicKey([]byte("Some data"), signature)
+		assert.NoError(t, err)
+		assert.True(t, privateKey.PubKey().IsEqual(pub.(*secp256k1.PublicKey)))
+
+		signature[64] = 2
+		_, err = recoverer.RecoverPublicKey([]byte("Some data"), signature)
+		assert.ErrorIs(t, err, protocol.ErrInvalidSignature)
+		_, err = recoverer.RecoverPublicKey([]byte("Some data"), signature[:64])
+		assert.ErrorIs(t, err, protocol.ErrInvalidSignature)
+	})
+
+	t.Run("Message without public key", func(t *testing.T) {
+		domain := protocol.NewDomain("channel", "chaincode", "Transfer")
+		msg := &protocol.Message{Nonce: 1}
+		assert.NoError(t, msg.GenerateSignature(domain, privateKey, "to", "1"))
+		assert.Equal(t, protocol.SECP256K1, msg.Algorithm)
+
+		// Clients signing with wallets only send the signature
+		msg.PublicKey = ""
+		addr, err := msg.VerifyAgainstArgs(domain, "to", "1")
+		assert.NoError(t, err)
+		assert.Equal(t, library.Address(testEthereumAddress), addr)
+
+		// Tampered arguments recover another sender
+		addr, _ = msg.VerifyAgainstArgs(domain, "to", "2")
+		assert.NotEqual(t, library.Address(testEthereumAddress), addr)
+
+		// A carried public key must match the signature
+		other, err := secp256k1.GeneratePrivateKey()
+		assert.NoError(t, err)
+		msg.PublicKey = base64.StdEncoding.EncodeToString(other.PubKey().SerializeUncompressed())
+		_, err = msg.VerifyAgainstArgs(domain, "to", "1")
+		assert.ErrorIs(t, err, protocol.ErrInvalidMessage)
+	})
+}
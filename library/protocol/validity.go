@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrMessageNotYetValid is returned when a message is submitted before its ValidAfter.
+	ErrMessageNotYetValid = errors.New("message not yet valid")
+
+	// ErrMessageExpired is returned when a message is submitted after its ValidUntil.
+	ErrMessageExpired = errors.New("message expired")
+)
+
+// ValidityError tells why a message is out of its validity window at the transaction timestamp.
+// It matches ErrMessageNotYetValid or ErrMessageExpired with `errors.Is`.
+type ValidityError struct {
+	TxTimestamp int64
+	ValidAfter  int64
+	ValidUntil  int64
+
+	err error
+}
+
+func (e *ValidityError) Error() string {
+	return fmt.Sprintf("%s: tx timestamp %d, valid after %d, valid until %d", e.err.Error(), e.TxTimestamp, e.ValidAfter, e.ValidUntil)
+}
+
+func (e *ValidityError) Unwrap() error {
+	return e.err
+}
+
+// CheckValidity checks the message's validity window against the unix timestamp(in seconds).
+// Both bounds are inclusive and an unset bound is not checked.
+func (msg *Message) CheckValidity(timestamp int64) error {
+	var err error
+	switch {
+	case msg.ValidAfter != 0 && timestamp < msg.ValidAfter:
+		err = ErrMessageNotYetValid
+	case msg.ValidUntil != 0 && timestamp > msg.ValidUntil:
+		err = ErrMessageExpired
+	default:
+		return nil
+	}
+	return &ValidityError{
+		TxTimestamp: timestamp,
+		ValidAfter:  msg.ValidAfter,
+		ValidUntil:  msg.ValidUntil,
+		err:         err,
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidity(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	domain := protocol.NewDomain("channel", "erc20", "Transfer")
+
+	t.Run("CheckValidity", func(t *testing.T) {
+		msg := &protocol.Message{}
+		assert.NoError(t, msg.CheckValidity(100))
+
+		msg = &protocol.Message{ValidAfter: 100, ValidUntil: 200}
+		assert.NoError(t, msg.CheckValidity(100))
+		assert.NoError(t, msg.CheckValidity(200))
+		assert.ErrorIs(t, msg.CheckValidity(99), protocol.ErrMessageNotYetValid)
+
+		err := msg.CheckValidity(201)
+		assert.ErrorIs(t, err, protocol.ErrMessageExpired)
+		var validityErr *protocol.ValidityError
+		assert.True(t, errors.As(err, &validityErr))
+		assert.Equal(t, int64(201), validityErr.TxTimestamp)
+		assert.Equal(t, int64(200), validityErr.ValidUntil)
+	})
+
+	t.Run("Covered by signature", func(t *testing.T) {
+		msg := &protocol.Message{Nonce: 1, ValidUntil: 200}
+		assert.NoError(t, msg.GenerateSignature(domain, privateKey, "to", "1"))
+		_, err := msg.VerifyAgainstArgs(domain, "to", "1")
+		assert.NoError(t, err)
+
+		for _, window := range [][2]int64{{0, 300}, {0, 0}, {100, 200}} {
+			extended := *msg
+			extended.ValidAfter, extended.ValidUntil = window[0], window[1]
+			_, err = extended.VerifyAgainstArgs(domain, "to", "1")
+			assert.ErrorIs(t, err, protocol.ErrInvalidMessage)
+		}
+	})
+}
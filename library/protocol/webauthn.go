@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidAssertion is returned when a WebAuthn assertion can not be verified.
+	ErrInvalidAssertion = errors.New("invalid webauthn assertion")
+)
+
+const (
+	// WebAuthnGet is the type of the client data of an assertion
+	WebAuthnGet = "webauthn.get"
+
+	// FlagUserPresent is set in the authenticator data when the user is present
+	FlagUserPresent byte = 0x01
+	// FlagUserVerified is set in the authenticator data when the user is verified
+	FlagUserVerified byte = 0x04
+
+	// authenticatorDataMinLength is the length of rpIdHash(32) || flags(1) || signCount(4)
+	authenticatorDataMinLength = 37
+)
+
+// WebAuthnAssertion is the signature of a message signed by a passkey.
+// The authenticator signs `AuthenticatorData || sha256(ClientDataJSON)` and the challenge of `ClientDataJSON` is the message digest.
+type WebAuthnAssertion struct {
+	AuthenticatorData []byte `json:"authenticatorData"`
+	ClientDataJSON    []byte `json:"clientDataJSON"`
+	Signature         []byte `json:"signature"`
+}
+
+// CollectedClientData is the part of the client data we verify
+type CollectedClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// Challenge returns the challenge which a passkey signs for the digest
+func Challenge(digest []byte) string {
+	return base64.RawURLEncoding.EncodeToString(digest)
+}
+
+// webAuthnAlgorithm verifies WebAuthn assertions of P-256 passkeys.
+// The signature of a message is its JSON encoded WebAuthnAssertion and its public key is in PKIX form
+// which is returned by `AuthenticatorAttestationResponse.getPublicKey()` when the passkey is created.
+type webAuthnAlgorithm struct {
+	ecdsaAlgorithm
+
+	// rpIDHashes are the sha256 hashes of the relying party ids accepted
+	rpIDHashes [][]byte
+}
+
+// NewWebAuthnAlgorithm creates the WebAuthn algorithm which only accepts assertions of the relying party ids.
+// It is not registered by default because relying party ids are different in each deployment:
+//
+//	protocol.RegisterAlgorithm(protocol.WEBAUTHN, protocol.NewWebAuthnAlgorithm("bestchains.com"))
+func NewWebAuthnAlgorithm(rpIDs ...string) KeyAlgorithm {
+	algorithm := webAuthnAlgorithm{}
+	for _, rpID := range rpIDs {
+		hashed := sha256.Sum256([]byte(rpID))
+		algorithm.rpIDHashes = append(algorithm.rpIDHashes, hashed[:])
+	}
+	return algorithm
+}
+
+func (algorithm webAuthnAlgorithm) ParsePublicKey(raw []byte) (crypto.PublicKey, error) {
+	pub, err := algorithm.ecdsaAlgorithm.ParsePublicKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	if pub.(*ecdsa.PublicKey).Curve != elliptic.P256() {
+		return nil, ErrInvalidKey
+	}
+	return pub, nil
+}
+
+// Sign signs the digest as a software authenticator of the first relying party id,
+// which helps clients without passkeys and tests.
+func (algorithm webAuthnAlgorithm) Sign(priv crypto.PrivateKey, digest []byte) ([]byte, error) {
+	privKey, ok := priv.(*ecdsa.PrivateKey)
+	if !ok || privKey.Curve != elliptic.P256() {
+		return nil, ErrInvalidKey
+	}
+	if len(algorithm.rpIDHashes) == 0 {
+		return nil, errors.Wrap(ErrInvalidAssertion, "no relying party id")
+	}
+
+	clientDataJSON, err := json.Marshal(CollectedClientData{Type: WebAuthnGet, Challenge: Challenge(digest)})
+	if err != nil {
+		return nil, err
+	}
+	authenticatorData := append(append([]byte{}, algorithm.rpIDHashes[0]...), FlagUserPresent|FlagUserVerified, 0, 0, 0, 0)
+	signature, err := ecdsa.SignASN1(rand.Reader, privKey, assertionHash(authenticatorData, clientDataJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(WebAuthnAssertion{
+		AuthenticatorData: authenticatorData,
+		ClientDataJSON:    clientDataJSON,
+		Signature:         signature,
+	})
+}
+
+func (algorithm webAuthnAlgorithm) Verify(pub crypto.PublicKey, digest []byte, signature []byte) bool {
+	return algorithm.verifyAssertion(pub, digest, signature) == nil
+}
+
+// verifyAssertion verifies the JSON encoded WebAuthnAssertion against the digest and tells why it is invalid
+func (algorithm webAuthnAlgorithm) verifyAssertion(pub crypto.PublicKey, digest []byte, signature []byte) error {
+	pubKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok || pubKey.Curve != elliptic.P256() {
+		return ErrInvalidKey
+	}
+
+	assertion := new(WebAuthnAssertion)
+	if err := json.Unmarshal(signature, assertion); err != nil {
+		return errors.Wrap(ErrInvalidAssertion, err.Error())
+	}
+
+	// The client data must be an assertion of the digest
+	clientData := new(CollectedClientData)
+	if err := json.Unmarshal(assertion.ClientDataJSON, clientData); err != nil {
+		return errors.Wrap(ErrInvalidAssertion, err.Error())
+	}
+	if clientData.Type != WebAuthnGet {
+		return errors.Wrapf(ErrInvalidAssertion, "client data type %s", clientData.Type)
+	}
+	if clientData.Challenge != Challenge(digest) {
+		return errors.Wrap(ErrInvalidAssertion, "challenge mismatch")
+	}
+
+	// The authenticator data must be of an accepted relying party with the user present
+	authenticatorData := assertion.AuthenticatorData
+	if len(authenticatorData) < authenticatorDataMinLength {
+		return errors.Wrap(ErrInvalidAssertion, "authenticator data too short")
+	}
+	if !algorithm.acceptRPIDHash(authenticatorData[:32]) {
+		return errors.Wrap(ErrInvalidAssertion, "relying party id mismatch")
+	}
+	if authenticatorData[32]&FlagUserPresent == 0 {
+		return errors.Wrap(ErrInvalidAssertion, "user not present")
+	}
+
+	if !ecdsa.VerifyASN1(pubKey, assertionHash(authenticatorData, assertion.ClientDataJSON), assertion.Signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (algorithm webAuthnAlgorithm) acceptRPIDHash(rpIDHash []byte) bool {
+	for _, accepted := range algorithm.rpIDHashes {
+		if bytes.Equal(accepted, rpIDHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// assertionHash is the hash an authenticator signs: sha256(authenticatorData || sha256(clientDataJSON))
+func assertionHash(authenticatorData []byte, clientDataJSON []byte) []byte {
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	hashed := sha256.Sum256(append(append([]byte{}, authenticatorData...), clientDataHash[:]...))
+	return hashed[:]
+}
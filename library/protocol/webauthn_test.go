@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package protocol_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebAuthn(t *testing.T) {
+	protocol.RegisterAlgorithm(protocol.WEBAUTHN, protocol.NewWebAuthnAlgorithm("bestchains.com"))
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	var sender = new(library.Address)
+	assert.NoError(t, sender.FromPublicKey(&privateKey.PublicKey))
+	publicKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.NoError(t, err)
+
+	domain := protocol.NewDomain("channel", "erc20", "Transfer")
+	msg := &protocol.Message{Nonce: 0, Version: protocol.MessageV2, Algorithm: protocol.WEBAUTHN}
+	digest, err := msg.Digest(domain, "to", "1")
+	assert.NoError(t, err)
+
+	// signedCall signs an assertion like a browser with passkey does
+	signedCall := func(rpID string, flags byte, clientData protocol.CollectedClientData) []string {
+		rpIDHash := sha256.Sum256([]byte(rpID))
+		authenticatorData := append(rpIDHash[:], flags, 0, 0, 0, 1)
+		clientDataJSON, err := json.Marshal(clientData)
+		assert.NoError(t, err)
+		clientDataHash := sha256.Sum256(clientDataJSON)
+		hashed := sha256.Sum256(append(append([]byte{}, authenticatorData...), clientDataHash[:]...))
+		signature, err := ecdsa.SignASN1(rand.Reader, privateKey, hashed[:])
+		assert.NoError(t, err)
+		assertion, err := json.Marshal(protocol.WebAuthnAssertion{
+			AuthenticatorData: authenticatorData,
+			ClientDataJSON:    clientDataJSON,
+			Signature:         signature,
+		})
+		assert.NoError(t, err)
+
+		signed := *msg
+		signed.PublicKey = base64.StdEncoding.EncodeToString(publicKey)
+		signed.Signature = base64.StdEncoding.EncodeToString(assertion)
+		bytes, err := signed.Marshal()
+		assert.NoError(t, err)
+		return []string{string(bytes), "to", "1"}
+	}
+	// verify verifies the signed call like the hooks do
+	verify := func(args []string) (library.Address, error) {
+		signed := new(protocol.Message)
+		assert.NoError(t, signed.Unmarshal([]byte(args[0])))
+		return signed.VerifyAgainstArgs(domain, args[1:]...)
+	}
+	clientData := protocol.CollectedClientData{Type: protocol.WebAuthnGet, Challenge: protocol.Challenge(digest), Origin: "https://bestchains.com"}
+
+	t.Run("Assertion", func(t *testing.T) {
+		addr, err := verify(signedCall("bestchains.com", protocol.FlagUserPresent, clientData))
+		assert.NoError(t, err)
+		assert.Equal(t, *sender, addr)
+	})
+
+	t.Run("Software authenticator", func(t *testing.T) {
+		signed := &protocol.Message{Nonce: 1, Algorithm: protocol.WEBAUTHN}
+		assert.NoError(t, signed.GenerateSignature(domain, privateKey, "to", "1"))
+		addr, err := signed.VerifyAgainstArgs(domain, "to", "1")
+		assert.NoError(t, err)
+		assert.Equal(t, *sender, addr)
+	})
+
+	t.Run("Invalid assertions", func(t *testing.T) {
+		otherChallenge := clientData
+		otherChallenge.Challenge = protocol.Challenge([]byte("another digest"))
+		otherType := clientData
+		otherType.Type = "webauthn.create"
+
+		for name, args := range map[string][]string{
+			"relying party":  signedCall("evil.com", protocol.FlagUserPresent, clientData),
+			"user presence":  signedCall("bestchains.com", protocol.FlagUserVerified, clientData),
+			"challenge":      signedCall("bestchains.com", protocol.FlagUserPresent, otherChallenge),
+			"client data":    signedCall("bestchains.com", protocol.FlagUserPresent, otherType),
+			"tampered input": append(signedCall("bestchains.com", protocol.FlagUserPresent, clientData)[:2], "2"),
+		} {
+			_, err := verify(args)
+			assert.ErrorIs(t, err, protocol.ErrInvalidMessage, name)
+		}
+	})
+}
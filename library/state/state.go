@@ -0,0 +1,366 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrEmptyKey is returned when the key is empty.
+	ErrEmptyKey = errors.New("empty key")
+
+	// ErrNilValue is returned when a nil value is put,use Delete instead.
+	ErrNilValue = errors.New("nil value")
+)
+
+// State is a transaction-scoped cache of the world state.
+// Reads go through to the stub only once per key and writes are kept until Flush,
+// so a transaction reads its own writes which fabric's stub does not.
+//
+// Keys are built from an object type and attributes: the object type is the key itself without attributes,
+// otherwise it is the composite key of the object type and attributes.
+//
+// Range queries(like `GetStateByPartialCompositeKey`) go to the stub directly and never see unflushed writes.
+type State struct {
+	stub    shim.ChaincodeStubInterface
+	entries map[string]*entry
+}
+
+type entry struct {
+	// value is nil if the key does not exist or is deleted
+	value []byte
+	dirty bool
+}
+
+// New creates an empty State on the stub
+func New(stub shim.ChaincodeStubInterface) *State {
+	return &State{
+		stub:    stub,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Key builds the key of objectType and attributes
+func (state *State) Key(objectType string, attributes ...string) (string, error) {
+	if len(attributes) == 0 {
+		return objectType, nil
+	}
+	key, err := state.stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return "", errors.Wrap(library.ErrInvalidCompositeKey, err.Error())
+	}
+	return key, nil
+}
+
+// Get returns the value of key,which is nil if the key does not exist
+func (state *State) Get(key string) ([]byte, error) {
+	if cached, ok := state.entries[key]; ok {
+		return cached.value, nil
+	}
+	value, err := state.stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	state.entries[key] = &entry{value: value}
+	return value, nil
+}
+
+// Put sets the value of key which is written to the stub by Flush.
+// Fabric deletes a key put with an empty value,so an empty value deletes key just like Delete does
+// and reads of the same tx see it deleted.
+func (state *State) Put(key string, value []byte) error {
+	if key == "" {
+		return ErrEmptyKey
+	}
+	if value == nil {
+		return ErrNilValue
+	}
+	if len(value) == 0 {
+		return state.Delete(key)
+	}
+	state.entries[key] = &entry{value: value, dirty: true}
+	return nil
+}
+
+// Delete deletes key which is deleted from the stub by Flush
+func (state *State) Delete(key string) error {
+	if key == "" {
+		return ErrEmptyKey
+	}
+	state.entries[key] = &entry{dirty: true}
+	return nil
+}
+
+// Flush writes all changes to the stub in the order of keys
+func (state *State) Flush() error {
+	keys := make([]string, 0, len(state.entries))
+	for key, cached := range state.entries {
+		if cached.dirty {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		cached := state.entries[key]
+		var err error
+		if cached.value == nil {
+			err = state.stub.DelState(key)
+		} else {
+			err = state.stub.PutState(key, cached.value)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "flush %s", key)
+		}
+		cached.dirty = false
+	}
+	return nil
+}
+
+// MigrateKeys moves the values of the composite keys of objectType to the keys whose attributes are renamed by rename,
+// like `library.CanonicalAttributes`. merge returns the value of the renamed key from the moved value
+// and the current value of the renamed key,which is nil if it does not exist.
+// The keys are listed by a range query on the stub,so the keys moved to are never moved again in the same tx.
+// It returns the number of keys moved,which is 0 once all of them are moved.
+func (state *State) MigrateKeys(objectType string, rename func(attributes []string) []string, merge func(moved []byte, current []byte) ([]byte, error)) (uint64, error) {
+	iterator, err := state.stub.GetStateByPartialCompositeKey(objectType, []string{})
+	if err != nil {
+		return 0, err
+	}
+	defer iterator.Close()
+
+	var count uint64
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return count, err
+		}
+		_, attributes, err := state.stub.SplitCompositeKey(kv.GetKey())
+		if err != nil {
+			return count, err
+		}
+		key, err := state.Key(objectType, rename(attributes)...)
+		if err != nil {
+			return count, err
+		}
+		if key == kv.GetKey() {
+			continue
+		}
+
+		moved, err := state.Get(kv.GetKey())
+		if err != nil {
+			return count, err
+		}
+		if moved == nil {
+			continue
+		}
+		current, err := state.Get(key)
+		if err != nil {
+			return count, err
+		}
+		value, err := merge(moved, current)
+		if err != nil {
+			return count, errors.Wrapf(err, "migrate %s", kv.GetKey())
+		}
+		if err = state.Put(key, value); err != nil {
+			return count, err
+		}
+		if err = state.Delete(kv.GetKey()); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// SumUint256 merges two Uint256 values in decimal by adding them,like the balances of the same account
+func SumUint256(moved []byte, current []byte) ([]byte, error) {
+	var sum math.Uint256
+	for _, value := range [][]byte{moved, current} {
+		if value == nil {
+			continue
+		}
+		parsed, err := math.ParseUint256(string(value))
+		if err != nil {
+			return nil, err
+		}
+		ok, added := sum.TryAdd(parsed)
+		if !ok {
+			return nil, math.ErrMathOpOverflowed
+		}
+		sum = added
+	}
+	return []byte(sum.String()), nil
+}
+
+// KeepCurrent merges two values by keeping the current one if it exists,like an allowance set again after the moved one
+func KeepCurrent(moved []byte, current []byte) ([]byte, error) {
+	if current != nil {
+		return current, nil
+	}
+	return moved, nil
+}
+
+// counterStore reads and writes persistent counters through the state
+type counterStore struct {
+	*State
+}
+
+func (store counterStore) GetTxID() string {
+	return store.stub.GetTxID()
+}
+
+func (store counterStore) GetState(key string) ([]byte, error) {
+	return store.Get(key)
+}
+
+func (store counterStore) PutState(key string, value []byte) error {
+	return store.Put(key, value)
+}
+
+func (store counterStore) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return store.stub.CreateCompositeKey(objectType, attributes)
+}
+
+// CounterStore returns the store of `library.PersistentCounter` on this state,
+// so a counter reads its own increments of the same tx
+func (state *State) CounterStore() library.CounterStore {
+	return counterStore{State: state}
+}
+
+// get returns the value of the key of objectType and attributes
+func (state *State) get(objectType string, attributes []string) ([]byte, error) {
+	key, err := state.Key(objectType, attributes...)
+	if err != nil {
+		return nil, err
+	}
+	return state.Get(key)
+}
+
+// put sets the value of the key of objectType and attributes
+func (state *State) put(value []byte, objectType string, attributes []string) error {
+	key, err := state.Key(objectType, attributes...)
+	if err != nil {
+		return err
+	}
+	return state.Put(key, value)
+}
+
+// GetString returns the string value,which is empty if the key does not exist
+func (state *State) GetString(objectType string, attributes ...string) (string, error) {
+	value, err := state.get(objectType, attributes)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// PutString sets the string value,an empty string deletes the key
+func (state *State) PutString(value string, objectType string, attributes ...string) error {
+	return state.put([]byte(value), objectType, attributes)
+}
+
+// GetUint64 returns the uint64 value in decimal,which is 0 if the key does not exist
+func (state *State) GetUint64(objectType string, attributes ...string) (uint64, error) {
+	value, err := state.get(objectType, attributes)
+	if err != nil {
+		return 0, err
+	}
+	return library.BytesToUint64(value)
+}
+
+// PutUint64 sets the uint64 value in decimal
+func (state *State) PutUint64(value uint64, objectType string, attributes ...string) error {
+	return state.put([]byte(library.Uint64ToString(value)), objectType, attributes)
+}
+
+// GetUint256 returns the Uint256 value in decimal,which is 0 if the key does not exist.
+// A value put by PutUint64 is decoded as it is.
+func (state *State) GetUint256(objectType string, attributes ...string) (math.Uint256, error) {
+	value, err := state.get(objectType, attributes)
+	if err != nil {
+		return math.Uint256{}, err
+	}
+	if value == nil {
+		return math.Uint256{}, nil
+	}
+	return math.ParseUint256(string(value))
+}
+
+// PutUint256 sets the Uint256 value in decimal
+func (state *State) PutUint256(value math.Uint256, objectType string, attributes ...string) error {
+	return state.put([]byte(value.String()), objectType, attributes)
+}
+
+// GetBool returns the Bool value,which is False if the key does not exist
+func (state *State) GetBool(objectType string, attributes ...string) (library.Bool, error) {
+	value, err := state.get(objectType, attributes)
+	if err != nil {
+		return library.False, err
+	}
+	return library.BytesToBool(value), nil
+}
+
+// PutBool sets the Bool value
+func (state *State) PutBool(value library.Bool, objectType string, attributes ...string) error {
+	return state.put(value.Bytes(), objectType, attributes)
+}
+
+// GetAddress returns the Address value,which is empty if the key does not exist
+func (state *State) GetAddress(objectType string, attributes ...string) (library.Address, error) {
+	value, err := state.get(objectType, attributes)
+	if err != nil {
+		return "", err
+	}
+	return library.Address(value), nil
+}
+
+// PutAddress sets the Address value,an empty address deletes the key
+func (state *State) PutAddress(value library.Address, objectType string, attributes ...string) error {
+	return state.put([]byte(value.String()), objectType, attributes)
+}
+
+// GetJSON unmarshals the JSON value into v and tells whether the key exists
+func (state *State) GetJSON(v interface{}, objectType string, attributes ...string) (bool, error) {
+	value, err := state.get(objectType, attributes)
+	if err != nil {
+		return false, err
+	}
+	if value == nil {
+		return false, nil
+	}
+	if err = json.Unmarshal(value, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PutJSON sets the JSON value of v
+func (state *State) PutJSON(v interface{}, objectType string, attributes ...string) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return state.put(value, objectType, attributes)
+}
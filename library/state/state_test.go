@@ -0,0 +1,214 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state_test
+
+import (
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/math"
+	"github.com/bestchains/bestchains-contracts/library/state"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStub() *shimtest.MockStub {
+	stub := shimtest.NewMockStub("state", nil)
+	stub.MockTransactionStart("tx")
+	return stub
+}
+
+func TestState(t *testing.T) {
+	t.Run("Key", func(t *testing.T) {
+		stub := newStub()
+		st := state.New(stub)
+
+		key, err := st.Key("totalSupply")
+		assert.NoError(t, err)
+		assert.Equal(t, "totalSupply", key)
+
+		key, err = st.Key("balance~account", "0x1")
+		assert.NoError(t, err)
+		expected, _ := stub.CreateCompositeKey("balance~account", []string{"0x1"})
+		assert.Equal(t, expected, key)
+
+		_, err = st.Key("balance~account", "\x00")
+		assert.ErrorIs(t, err, library.ErrInvalidCompositeKey)
+	})
+
+	t.Run("Read through", func(t *testing.T) {
+		stub := newStub()
+		assert.NoError(t, stub.PutState("key", []byte("1")))
+		st := state.New(stub)
+
+		value, err := st.Get("key")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("1"), value)
+
+		// later reads are served by the cache
+		assert.NoError(t, stub.PutState("key", []byte("2")))
+		value, err = st.Get("key")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("1"), value)
+
+		value, err = st.Get("missing")
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+	})
+
+	t.Run("Write back", func(t *testing.T) {
+		stub := newStub()
+		assert.NoError(t, stub.PutState("deleted", []byte("1")))
+		st := state.New(stub)
+
+		assert.NoError(t, st.Put("key", []byte("1")))
+		assert.NoError(t, st.Delete("deleted"))
+		assert.ErrorIs(t, st.Put("key", nil), state.ErrNilValue)
+		assert.ErrorIs(t, st.Put("", []byte("1")), state.ErrEmptyKey)
+
+		// writes are read back before they are flushed
+		value, err := st.Get("key")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("1"), value)
+		value, err = st.Get("deleted")
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+
+		value, _ = stub.GetState("key")
+		assert.Nil(t, value)
+		value, _ = stub.GetState("deleted")
+		assert.Equal(t, []byte("1"), value)
+
+		assert.NoError(t, st.Flush())
+		value, _ = stub.GetState("key")
+		assert.Equal(t, []byte("1"), value)
+		value, _ = stub.GetState("deleted")
+		assert.Nil(t, value)
+	})
+
+	t.Run("Typed accessors", func(t *testing.T) {
+		stub := newStub()
+		st := state.New(stub)
+
+		balance, err := st.GetUint64("balance", "0x1")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), balance)
+		assert.NoError(t, st.PutUint64(100, "balance", "0x1"))
+		balance, err = st.GetUint64("balance", "0x1")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(100), balance)
+
+		supply, err := st.GetUint256("supply")
+		assert.NoError(t, err)
+		assert.True(t, supply.IsZero())
+		// uint64 values are decoded as Uint256
+		supply, err = st.GetUint256("balance", "0x1")
+		assert.NoError(t, err)
+		assert.Equal(t, math.NewUint256(100), supply)
+		assert.NoError(t, st.PutUint256(math.MaxUint256, "supply"))
+		supply, err = st.GetUint256("supply")
+		assert.NoError(t, err)
+		assert.Equal(t, math.MaxUint256, supply)
+		_, err = st.GetUint64("supply")
+		assert.Error(t, err)
+
+		enabled, err := st.GetBool("enabled")
+		assert.NoError(t, err)
+		assert.Equal(t, library.False, enabled)
+		assert.NoError(t, st.PutBool(library.True, "enabled"))
+		enabled, err = st.GetBool("enabled")
+		assert.NoError(t, err)
+		assert.Equal(t, library.True, enabled)
+
+		assert.NoError(t, st.PutAddress(library.ZeroAddress, "owner"))
+		owner, err := st.GetAddress("owner")
+		assert.NoError(t, err)
+		assert.Equal(t, library.ZeroAddress, owner)
+
+		assert.NoError(t, st.PutString("bestchains", "name"))
+		name, err := st.GetString("name")
+		assert.NoError(t, err)
+		assert.Equal(t, "bestchains", name)
+
+		type record struct {
+			Owner string `json:"owner"`
+		}
+		var got record
+		found, err := st.GetJSON(&got, "record", "1")
+		assert.NoError(t, err)
+		assert.False(t, found)
+		assert.NoError(t, st.PutJSON(record{Owner: "0x1"}, "record", "1"))
+		found, err = st.GetJSON(&got, "record", "1")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, record{Owner: "0x1"}, got)
+
+		assert.NoError(t, st.Flush())
+		key, _ := stub.CreateCompositeKey("balance", []string{"0x1"})
+		value, _ := stub.GetState(key)
+		assert.Equal(t, []byte("100"), value)
+		value, _ = stub.GetState("enabled")
+		assert.Equal(t, library.True.Bytes(), value)
+	})
+
+	t.Run("Empty values", func(t *testing.T) {
+		stub := newStub()
+		assert.NoError(t, stub.PutState("name", []byte("token")))
+		st := state.New(stub)
+
+		// an empty value deletes the key just like fabric does
+		assert.NoError(t, st.Put("name", []byte{}))
+		value, err := st.Get("name")
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+
+		assert.NoError(t, st.PutUint256(math.Uint256{}, "supply"))
+		assert.NoError(t, st.Flush())
+		value, _ = stub.GetState("name")
+		assert.Nil(t, value)
+
+		// zero numbers are stored as they are
+		value, _ = stub.GetState("supply")
+		assert.Equal(t, []byte("0"), value)
+	})
+
+	t.Run("Invalid value", func(t *testing.T) {
+		stub := newStub()
+		assert.NoError(t, stub.PutState("balance", []byte("not a number")))
+		_, err := state.New(stub).GetUint64("balance")
+		assert.Error(t, err)
+	})
+	t.Run("Counter store", func(t *testing.T) {
+		stub := newStub()
+		st := state.New(stub)
+		counter := library.NewPersistentCounter(st.CounterStore(), "nonce~account", "0x1")
+
+		// the counter reads its own increments before they are flushed
+		assert.NoError(t, counter.Increment(1))
+		assert.NoError(t, counter.Increment(1))
+		curr, err := counter.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2), curr)
+
+		key, _ := st.Key("nonce~account", "0x1")
+		value, _ := stub.GetState(key)
+		assert.Nil(t, value)
+		assert.NoError(t, st.Flush())
+		value, _ = stub.GetState(key)
+		assert.Equal(t, []byte("2"), value)
+	})
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNoEventSource is returned when events are listened without an EventSource
+	ErrNoEventSource = errors.New("no event source")
+)
+
+// EventSource streams chaincode events,which is implemented by fabric-gateway's `*client.Network`
+type EventSource interface {
+	ChaincodeEvents(ctx context.Context, chaincodeName string, options ...client.ChaincodeEventsOption) (<-chan *client.ChaincodeEvent, error)
+}
+
+// DecodeEvents decodes the events emitted by a transaction from a chaincode event
+func DecodeEvents(event *client.ChaincodeEvent) ([]protocol.Event, error) {
+	events, err := protocol.DecodeEvents(event.EventName, event.Payload)
+	if err != nil {
+		return nil, err
+	}
+	for index := range events {
+		if events[index].TxID == "" {
+			events[index].TxID = event.TransactionID
+		}
+	}
+	return events, nil
+}
+
+// UnmarshalEvent decodes the payload of an event into v,for example `*erc20.EventTransfer`
+func UnmarshalEvent(event protocol.Event, v interface{}) error {
+	return json.Unmarshal(event.Payload, v)
+}
+
+// Events streams the events emitted by the client's chaincode one by one.
+// Chaincode events which can not be decoded are skipped.
+// The channel is closed when ctx is done.
+func (c *Client) Events(ctx context.Context, options ...client.ChaincodeEventsOption) (<-chan protocol.Event, error) {
+	if c.events == nil {
+		return nil, ErrNoEventSource
+	}
+	chaincodeEvents, err := c.events.ChaincodeEvents(ctx, c.contract.ChaincodeName(), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan protocol.Event)
+	go func() {
+		defer close(events)
+		for chaincodeEvent := range chaincodeEvents {
+			decoded, err := DecodeEvents(chaincodeEvent)
+			if err != nil {
+				continue
+			}
+			for _, event := range decoded {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
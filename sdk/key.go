@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidPrivateKey is returned when a signer key can not be parsed
+	ErrInvalidPrivateKey = errors.New("invalid private key")
+)
+
+// LoadPrivateKey loads the signer key from a file,see ParsePrivateKey for supported formats
+func LoadPrivateKey(path string) (crypto.PrivateKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(raw)
+}
+
+// ParsePrivateKey parses a signer key which is one of
+//   - PEM encoded PKCS #8 keys(ECDSA and ED25519) like the keystore of Fabric CA
+//   - PEM encoded SEC 1 ECDSA keys
+//   - hex encoded secp256k1 keys(with or without `0x`) exported by ethereum wallets
+func ParsePrivateKey(raw []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return parseHexPrivateKey(string(raw))
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidPrivateKey, err.Error())
+		}
+		return priv, nil
+	case "EC PRIVATE KEY":
+		priv, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidPrivateKey, err.Error())
+		}
+		return priv, nil
+	default:
+		return nil, errors.Wrapf(ErrInvalidPrivateKey, "unsupported PEM block %s", block.Type)
+	}
+}
+
+func parseHexPrivateKey(str string) (crypto.PrivateKey, error) {
+	str = strings.TrimPrefix(strings.TrimSpace(str), "0x")
+	raw, err := hex.DecodeString(str)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidPrivateKey, err.Error())
+	}
+	if len(raw) != secp256k1.PrivKeyBytesLen {
+		return nil, errors.Wrapf(ErrInvalidPrivateKey, "secp256k1 key must be %d bytes", secp256k1.PrivKeyBytesLen)
+	}
+	return secp256k1.PrivKeyFromBytes(raw), nil
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sdk builds,signs and submits the calls of bestchains contracts with fabric-gateway.
+package sdk
+
+import (
+	"crypto"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/pkg/errors"
+)
+
+const (
+	// NonceFunction is the function which returns the current nonce of an account
+	NonceFunction = "Current"
+)
+
+// Contract invokes the transactions of a contract,which is implemented by fabric-gateway's `*client.Contract`
+type Contract interface {
+	ChaincodeName() string
+	ContractName() string
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+}
+
+var _ Contract = new(client.Contract)
+var _ EventSource = new(client.Network)
+
+// Client signs the calls to a contract with its signer key
+type Client struct {
+	channelID    string
+	contract     Contract
+	contractName string
+	events       EventSource
+
+	signer    crypto.PrivateKey
+	address   library.Address
+	delegator library.Address
+
+	version  protocol.MessageVersion
+	validFor time.Duration
+	now      func() time.Time
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithMessageVersion signs messages with version instead of protocol.LatestMessageVersion
+func WithMessageVersion(version protocol.MessageVersion) Option {
+	return func(c *Client) {
+		c.version = version
+	}
+}
+
+// WithValidFor limits signed messages to be valid for duration since they are signed
+func WithValidFor(duration time.Duration) Option {
+	return func(c *Client) {
+		c.validFor = duration
+	}
+}
+
+// WithDelegator signs messages with the signer as a session key of delegator,
+// so the calls are sent by delegator if it delegates the signer in the delegation contract
+func WithDelegator(delegator library.Address) Option {
+	return func(c *Client) {
+		c.delegator = delegator
+	}
+}
+
+// WithContractName signs messages for the contract registered as name.
+// It is required when the client calls the default contract of a chaincode without its name,
+// as messages are bound to the registered name of the contract.
+func WithContractName(name string) Option {
+	return func(c *Client) {
+		c.contractName = name
+	}
+}
+
+// WithEventSource sets where the client listens events from
+func WithEventSource(events EventSource) Option {
+	return func(c *Client) {
+		c.events = events
+	}
+}
+
+// New creates a Client of the contract `contractName` in chaincode on the network(channel).
+// contractName is empty for the default contract of the chaincode,
+// whose registered name must be set by WithContractName.
+func New(network *client.Network, chaincode string, contractName string, signer crypto.PrivateKey, opts ...Option) (*Client, error) {
+	opts = append([]Option{WithEventSource(network)}, opts...)
+	return NewWithContract(network.Name(), network.GetContractWithName(chaincode, contractName), signer, opts...)
+}
+
+// NewWithContract creates a Client of contract on the channel
+func NewWithContract(channelID string, contract Contract, signer crypto.PrivateKey, opts ...Option) (*Client, error) {
+	algorithm, err := protocol.GetAlgorithm(protocol.AlgorithmOf(signer))
+	if err != nil {
+		return nil, err
+	}
+	pub, err := algorithm.Public(signer)
+	if err != nil {
+		return nil, err
+	}
+	address, err := algorithm.Address(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		channelID:    channelID,
+		contract:     contract,
+		contractName: contract.ContractName(),
+		signer:       signer,
+		address:      address,
+		version:      protocol.LatestMessageVersion,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Address returns the address of the signer
+func (c *Client) Address() library.Address {
+	return c.address
+}
+
+// Sender returns the `MsgSender` of signed calls,which is the delegator if it is set
+func (c *Client) Sender() library.Address {
+	if c.delegator != "" {
+		return c.delegator
+	}
+	return c.address
+}
+
+// Nonce returns the current nonce of the sender
+func (c *Client) Nonce() (uint64, error) {
+	result, err := c.contract.EvaluateTransaction(NonceFunction, c.Sender().String())
+	if err != nil {
+		return 0, errors.Wrap(err, "query nonce")
+	}
+	return strconv.ParseUint(string(result), 10, 64)
+}
+
+// Sign signs the call of function with the current nonce
+// and returns the arguments with the message at args[0]
+func (c *Client) Sign(function string, args ...string) ([]string, error) {
+	nonce, err := c.Nonce()
+	if err != nil {
+		return nil, err
+	}
+	return c.SignWithNonce(nonce, function, args...)
+}
+
+// SignWithNonce signs the call of function with nonce
+// and returns the arguments with the message at args[0]
+func (c *Client) SignWithNonce(nonce uint64, function string, args ...string) ([]string, error) {
+	msg := &protocol.Message{
+		Nonce:     nonce,
+		Version:   c.version,
+		Delegator: c.delegator.String(),
+	}
+	if c.validFor > 0 {
+		msg.ValidUntil = c.now().Add(c.validFor).Unix()
+	}
+
+	if err := msg.GenerateSignature(c.domain(function), c.signer, args...); err != nil {
+		return nil, err
+	}
+	bytes, err := msg.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{string(bytes)}, args...), nil
+}
+
+// Submit signs the call of function and submits it to be committed.
+// Calls of one signer must be submitted one by one as each of them uses the current nonce.
+func (c *Client) Submit(function string, args ...string) ([]byte, error) {
+	signed, err := c.Sign(function, args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.contract.SubmitTransaction(function, signed...)
+}
+
+// Evaluate signs the call of function and evaluates it without committing
+func (c *Client) Evaluate(function string, args ...string) ([]byte, error) {
+	signed, err := c.Sign(function, args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.contract.EvaluateTransaction(function, signed...)
+}
+
+// Query evaluates the call of an unsigned function
+func (c *Client) Query(function string, args ...string) ([]byte, error) {
+	return c.contract.EvaluateTransaction(function, args...)
+}
+
+// domain returns the signing domain of function,
+// which is qualified by the contract name just like fabric-gateway does
+func (c *Client) domain(function string) protocol.Domain {
+	if c.contractName != "" {
+		function = c.contractName + ":" + function
+	}
+	return protocol.NewDomain(c.channelID, c.contract.ChaincodeName(), function)
+}
+
+// Decode decodes the result of a transaction into v.
+// Strings are returned as they are by contracts,others are JSON encoded.
+func Decode(result []byte, v interface{}) error {
+	if str, ok := v.(*string); ok {
+		*str = string(result)
+		return nil
+	}
+	return json.Unmarshal(result, v)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The Bestchains Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"strconv"
+	"testing"
+
+	"github.com/bestchains/bestchains-contracts/library"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/bestchains/bestchains-contracts/sdk"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var errInvalidNonce = errors.New("invalid nonce")
+
+// fakeContract verifies signed calls like the hooks of a contract do
+type fakeContract struct {
+	nonces map[string]uint64
+	sender library.Address
+}
+
+// sender returns who sends the message,which is the delegator if it is set
+func sender(msg *protocol.Message, signer library.Address) library.Address {
+	if msg.Delegator != "" {
+		return library.Address(msg.Delegator)
+	}
+	return signer
+}
+
+func (contract *fakeContract) ChaincodeName() string { return "erc20" }
+
+func (contract *fakeContract) ContractName() string { return "org.bestchains.com.ERC20Contract" }
+
+func (contract *fakeContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	if name == sdk.NonceFunction {
+		return []byte(strconv.FormatUint(contract.nonces[args[0]], 10)), nil
+	}
+	return contract.verify(name, args...)
+}
+
+func (contract *fakeContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	result, err := contract.verify(name, args...)
+	if err != nil {
+		return nil, err
+	}
+	contract.nonces[contract.sender.String()]++
+	return result, nil
+}
+
+func (contract *fakeContract) verify(name string, args ...string) ([]byte, error) {
+	msg := new(protocol.Message)
+	if err := msg.Unmarshal([]byte(args[0])); err != nil {
+		return nil, err
+	}
+	domain := protocol.NewDomain("channel", contract.ChaincodeName(), contract.ContractName()+":"+name)
+	signer, err := msg.VerifyAgainstArgs(domain, args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	msgSender := sender(msg, signer)
+	if msg.Nonce != contract.nonces[msgSender.String()] {
+		return nil, errInvalidNonce
+	}
+	contract.sender = msgSender
+	return []byte(msgSender.String()), nil
+}
+
+func TestClient(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	var address = new(library.Address)
+	assert.NoError(t, address.FromPublicKey(&privateKey.PublicKey))
+
+	contract := &fakeContract{nonces: make(map[string]uint64)}
+	c, err := sdk.NewWithContract("channel", contract, privateKey)
+	assert.NoError(t, err)
+	assert.Equal(t, *address, c.Address())
+
+	for i := 0; i < 2; i++ {
+		result, err := c.Submit("Transfer", "to", "1")
+		assert.NoError(t, err)
+		var sender string
+		assert.NoError(t, sdk.Decode(result, &sender))
+		assert.Equal(t, address.String(), sender)
+	}
+
+	nonce, err := c.Nonce()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), nonce)
+
+	_, err = c.Evaluate("Transfer", "to", "1")
+	assert.NoError(t, err)
+
+	// a stale nonce is rejected
+	args, err := c.SignWithNonce(0, "Transfer", "to", "1")
+	assert.NoError(t, err)
+	_, err = contract.SubmitTransaction("Transfer", args...)
+	assert.ErrorIs(t, err, errInvalidNonce)
+
+	// signed for another function
+	_, err = contract.SubmitTransaction("Approve", args...)
+	assert.ErrorIs(t, err, protocol.ErrInvalidMessage)
+}
+
+func TestClientWithDelegator(t *testing.T) {
+	sessionKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	delegator := library.Address("0x2c7536e3605d9c16a7a3d7b1898e529396a65c23")
+
+	contract := &fakeContract{nonces: map[string]uint64{delegator.String(): 3}}
+	c, err := sdk.NewWithContract("channel", contract, sessionKey, sdk.WithDelegator(delegator))
+	assert.NoError(t, err)
+	assert.Equal(t, delegator, c.Sender())
+	assert.NotEqual(t, delegator, c.Address())
+
+	// signed with the nonce of the delegator
+	result, err := c.Submit("Transfer", "to", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, delegator.String(), string(result))
+	assert.Equal(t, uint64(4), contract.nonces[delegator.String()])
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.NoError(t, err)
+	parsed, err := sdk.ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+	assert.NoError(t, err)
+	assert.True(t, privateKey.Equal(parsed))
+
+	sec1, err := x509.MarshalECPrivateKey(privateKey)
+	assert.NoError(t, err)
+	parsed, err = sdk.ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}))
+	assert.NoError(t, err)
+	assert.True(t, privateKey.Equal(parsed))
+
+	parsed, err = sdk.ParsePrivateKey([]byte("0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318\n"))
+	assert.NoError(t, err)
+	c, err := sdk.NewWithContract("channel", &fakeContract{}, parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", c.Address().String())
+
+	_, err = sdk.ParsePrivateKey([]byte("0x4c08"))
+	assert.ErrorIs(t, err, sdk.ErrInvalidPrivateKey)
+	_, err = sdk.ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1}}))
+	assert.ErrorIs(t, err, sdk.ErrInvalidPrivateKey)
+}
+
+func TestDecodeEvents(t *testing.T) {
+	payload, err := json.Marshal(protocol.EventEnvelope{
+		Version: protocol.EventEnvelopeVersion,
+		Events: []protocol.Event{
+			{Name: "Transfer", Payload: json.RawMessage(`{"value":1}`), TxID: "tx"},
+			{Name: "Approve", Payload: json.RawMessage(`{"allowance":2}`), TxID: "tx"},
+		},
+	})
+	assert.NoError(t, err)
+
+	events, err := sdk.DecodeEvents(&client.ChaincodeEvent{
+		TransactionID: "tx",
+		EventName:     protocol.EventEnvelopeName,
+		Payload:       payload,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	var transfer struct {
+		Value uint64 `json:"value"`
+	}
+	assert.NoError(t, sdk.UnmarshalEvent(events[0], &transfer))
+	assert.Equal(t, uint64(1), transfer.Value)
+
+	// a legacy event is decoded as one event
+	events, err = sdk.DecodeEvents(&client.ChaincodeEvent{
+		TransactionID: "legacy",
+		EventName:     "Transfer",
+		Payload:       []byte(`{"value":3}`),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "Transfer", events[0].Name)
+	assert.Equal(t, "legacy", events[0].TxID)
+}
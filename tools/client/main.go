@@ -23,6 +23,9 @@ import (
 
 	"github.com/bestchains/bc-explorer/pkg/network"
 	"github.com/bestchains/bc-explorer/pkg/utils"
+	"github.com/bestchains/bestchains-contracts/library/protocol"
+	"github.com/bestchains/bestchains-contracts/sdk"
+	"github.com/pkg/errors"
 
 	gwclient "github.com/hyperledger/fabric-gateway/pkg/client"
 
@@ -32,10 +35,12 @@ import (
 )
 
 var (
-	profile  = flag.String("profile", "./network.json", "profile to connect with blockchain network")
-	contract = flag.String("contract", "depository", "contract name")
-	method   = flag.String("method", "org.hyperledger.fabric:GetMetadata", "contract method")
-	args     = new(utils.SliceArgs)
+	profile      = flag.String("profile", "./network.json", "profile to connect with blockchain network")
+	contract     = flag.String("contract", "depository", "contract name")
+	method       = flag.String("method", "org.hyperledger.fabric:GetMetadata", "contract method")
+	key          = flag.String("key", "", "signer key to sign the call with a message(unsigned if empty)")
+	contractName = flag.String("contract-name", "", "registered name of the default contract,which signs a method without the contract prefix")
+	args         = new(utils.SliceArgs)
 )
 
 func main() {
@@ -55,8 +60,7 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	contract := client.Channel("").GetContract(*contract)
-	resp, err := contract.SubmitTransaction(*method, *args...)
+	resp, err := submit(client.Channel(""))
 	if err != nil {
 		switch err := err.(type) {
 		case *gwclient.EndorseError, *gwclient.SubmitError, *gwclient.CommitStatusError:
@@ -71,3 +75,33 @@ func main() {
 	}
 	klog.Infof("Result: %s", resp)
 }
+
+// submit submits the call,which is signed by the key if it is set.
+// A signed call is bound to the registered name of the contract,
+// which is the namespace of the method or `-contract-name` for the default contract.
+func submit(channel *gwclient.Network) ([]byte, error) {
+	if *key == "" {
+		return channel.GetContract(*contract).SubmitTransaction(*method, *args...)
+	}
+
+	signer, err := sdk.LoadPrivateKey(*key)
+	if err != nil {
+		return nil, err
+	}
+	name, function := protocol.SplitFunction(*method)
+	if name != "" {
+		c, err := sdk.New(channel, *contract, name, signer)
+		if err != nil {
+			return nil, err
+		}
+		return c.Submit(function, *args...)
+	}
+	if *contractName == "" {
+		return nil, errors.New("-contract-name is required to sign a method of the default contract")
+	}
+	c, err := sdk.New(channel, *contract, "", signer, sdk.WithContractName(*contractName))
+	if err != nil {
+		return nil, err
+	}
+	return c.Submit(*method, *args...)
+}